@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"fpl-strategy-tester/internal"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runCompare lines up the results of two runs, and reports the differences between them
+func runCompare(args []string) {
+
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	runsDir := flags.String("runs", internal.RunsDir, "Directory holding the results directory of each run")
+	alpha := flags.Float64("alpha", 0.05, "Significance level below which a difference is flagged")
+	showAll := flags.Bool("all", false, "Show every row, including those which haven't changed")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		log.Fatalf("Error: expected two runs to compare, e.g. 'compare <run> <run>'")
+	}
+	dirA, dirB := runDir(*runsDir, flags.Arg(0)), runDir(*runsDir, flags.Arg(1))
+
+	// The comparable results tables are found from the strategies which can be run
+	strategies, err := internal.NewResolver().NewStrategies(nil)
+	if err != nil {
+		log.Fatalf("Error: %v\n", err)
+	}
+	tables := make([]internal.ResultsTable, 0)
+	for _, strategy := range strategies {
+		tables = append(tables, strategy.Tables()...)
+	}
+
+	comparison, err := internal.CompareRuns(dirA, dirB, tables)
+	if err != nil {
+		log.Fatalf("Error: %v\n", err)
+	}
+
+	fmt.Printf("A: %v\nB: %v\n\n", comparison.A.ID, comparison.B.ID)
+	if len(comparison.Settings) == 0 {
+		fmt.Printf("Both runs have the same settings\n")
+	}
+	for _, setting := range comparison.Settings {
+		fmt.Printf("%v\n", setting)
+	}
+
+	// Print the rows of each results table, flagging the differences unlikely to be down to chance
+	changed, significant := 0, 0
+	tests := make(map[string]bool)
+	file := ""
+	for _, row := range comparison.Rows {
+		if row.Tested {
+			tests[row.Test] = true
+		}
+		if !row.Changed() && !*showAll {
+			continue
+		}
+		if row.File != file {
+			file = row.File
+			fmt.Printf("\n%v\n%-10v%-34v%-20v%12v%12v%12v%10v%10v\n",
+				file, "Season", "Group", "Column", "A", "B", "Diff", "p-value", "Adjusted")
+		}
+		if row.Changed() {
+			changed++
+		}
+		if row.Significant(*alpha) {
+			significant++
+		}
+
+		if !row.InA || !row.InB {
+			only := "A"
+			if row.InB {
+				only = "B"
+			}
+			fmt.Printf("%-10v%-34vonly in run %v\n", row.Season, row.Group, only)
+			continue
+		}
+
+		pValue, adjusted, flag := "-", "-", ""
+		if row.Tested {
+			pValue, adjusted = fmt.Sprintf("%.4f", row.PValue), fmt.Sprintf("%.4f", row.AdjustedP)
+		}
+		if row.Significant(*alpha) {
+			flag = " *"
+		}
+		for key, value := range row.Values {
+			if key > 0 {
+				pValue, adjusted, flag = "", "", ""
+			}
+			fmt.Printf("%-10v%-34v%-20v%12.2f%12.2f%+12.2f%10v%10v%v\n",
+				row.Season, row.Group, value.Column, value.A, value.B, value.Diff(), pValue, adjusted, flag)
+		}
+	}
+
+	testNames := make([]string, 0)
+	for _, test := range []string{internal.PairedTest, internal.WelchTest} {
+		if tests[test] {
+			testNames = append(testNames, test)
+		}
+	}
+	fmt.Printf("\n%v of %v rows changed, %v significant at the %v level (marked *)\n",
+		changed, len(comparison.Rows), significant, *alpha)
+	if comparison.Tests > 0 {
+		fmt.Printf("%v rows tested (%v), with p-values adjusted for the number of tests by Holm's method\n",
+			comparison.Tests, strings.Join(testNames, ", "))
+	}
+}
+
+// runDir returns the directory of the run, which can be given as a path or as a run ID within the runs directory
+func runDir(runsDir, run string) string {
+	if _, err := os.Stat(run); err == nil {
+		return run
+	}
+	return filepath.Join(runsDir, run)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fpl-strategy-tester/internal/database"
+
+	"github.com/icelolly/go-errors"
+)
+
+// databaseFlags are the command line options used to configure the database connection
+type databaseFlags struct {
+	driver          *string
+	dsn             *string
+	sqlitePath      *string
+	configFile      *string
+	credentialsFile *string
+}
+
+// registerDatabaseFlags adds the database connection options to the sub-command's flags
+func registerDatabaseFlags(flags *flag.FlagSet) *databaseFlags {
+	return &databaseFlags{
+		driver:          flags.String("driver", "", "Database driver, either 'mysql' or 'sqlite3' (env "+database.EnvDriver+")"),
+		dsn:             flags.String("dsn", "", "Database connection string, or file path for SQLite (env "+database.EnvDSN+")"),
+		sqlitePath:      flags.String("sqlite", "", "SQLite database file, shorthand for -driver sqlite3 -dsn <file>"),
+		configFile:      flags.String("db-config", "", "JSON file containing the database 'driver' and 'dsn'"),
+		credentialsFile: flags.String("credentials", "", "MySQL 'credentials.json' file, see internal/database/credentials.example.json"),
+	}
+}
+
+// config resolves the database connection, taking the whole connection from the first source which gives a DSN:
+// the connection flags, the config file, the credentials file and finally the environment variables, so a
+// connection named on the command line always wins over the environment.
+// Settings are never mixed between sources, so a driver can't be paired with another source's DSN.
+func (f *databaseFlags) config() (database.Config, error) {
+	if *f.sqlitePath != "" && (*f.dsn != "" || *f.driver != "") {
+		return database.Config{}, errors.New("-sqlite can't be used together with -dsn or -driver")
+	}
+	if *f.driver != "" && *f.dsn == "" {
+		return database.Config{}, errors.New("-driver needs a -dsn to connect to")
+	}
+
+	if *f.sqlitePath != "" {
+		return database.Config{Driver: database.DriverSQLite, DSN: *f.sqlitePath}, nil
+	}
+	if *f.dsn != "" {
+		return database.Config{Driver: *f.driver, DSN: *f.dsn}, nil
+	}
+
+	if *f.configFile != "" {
+		config, err := database.ReadConfig(*f.configFile)
+		if err != nil {
+			return database.Config{}, errors.Wrap(err)
+		}
+		if config.DSN != "" {
+			return config, nil
+		}
+	}
+
+	if *f.credentialsFile != "" {
+		config, err := database.ReadCredentials(*f.credentialsFile)
+		if err != nil {
+			return database.Config{}, errors.Wrap(err)
+		}
+		return config, nil
+	}
+
+	if config := database.ConfigFromEnv(); config.DSN != "" {
+		return config, nil
+	}
+
+	return database.Config{}, errors.New("No database connection given, use -dsn, -sqlite, -db-config, " +
+		"-credentials or the " + database.EnvDSN + " environment variable")
+}
+
+// openDatabase connects to the configured database
+func (f *databaseFlags) openDatabase() (*database.Resolver, error) {
+	config, err := f.config()
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	repo := database.NewResolver(config)
+	if _, err := repo.ResolveFPLDB(); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	repo.ResolveQueryBuilder()
+	return repo, nil
+}
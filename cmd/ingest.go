@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fpl-strategy-tester/internal/database"
+	"log"
+)
+
+// runIngest creates the FPL database tables, and populates them from a local checkout of the vaastav dataset
+func runIngest(args []string) {
+
+	flags := flag.NewFlagSet("ingest", flag.ExitOnError)
+	dataDir := flags.String("dir", "", "Data directory of the vaastav dataset, e.g. Fantasy-Premier-League/data")
+	seasonList := flags.String("seasons", "", "Comma separated seasons to ingest, e.g. 2018-19,2019-20 (default all)")
+	dbFlags := registerDatabaseFlags(flags)
+	_ = flags.Parse(args)
+
+	if *dataDir == "" {
+		log.Fatalf("Error: the -dir flag is required")
+	}
+
+	seasons := parseList(*seasonList)
+	if len(seasons) == 0 {
+		var err error
+		if seasons, err = database.ListSeasons(*dataDir); err != nil {
+			log.Fatalf("Error: %v\n", err)
+		}
+	}
+
+	repo, err := dbFlags.openDatabase()
+	if err != nil {
+		log.Fatalf("Error: %v\n", err)
+	}
+	if err := repo.CreateTables(); err != nil {
+		log.Fatalf("Error: %v\n", err)
+	}
+
+	for _, season := range seasons {
+		log.Printf("-> [%v] Reading season data from %v...\t", season, *dataDir)
+		dataset, err := database.ReadDataset(*dataDir, season)
+		if err != nil {
+			log.Fatalf("Error: %v\n", err)
+		}
+
+		log.Printf("-> [%v] Writing %v players and %v game week rows...\t", season, len(dataset.Players), len(dataset.GWData))
+		if err := repo.Ingest(dataset); err != nil {
+			log.Fatalf("Error: %v\n", err)
+		}
+	}
+}
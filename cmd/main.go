@@ -1,46 +1,268 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"fpl-strategy-tester/internal"
 	"fpl-strategy-tester/internal/database"
 	"log"
-	"math/rand"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
 func main() {
 
+	// Run the sub-command requested, defaulting to the strategy simulation
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "ingest":
+			runIngest(os.Args[2:])
+			return
+		case "strategies":
+			listStrategies()
+			return
+		case "runs":
+			runRuns(os.Args[2:])
+			return
+		case "compare":
+			runCompare(os.Args[2:])
+			return
+		}
+	}
+	runSimulation(os.Args[1:])
+}
+
+// runSimulation simulates the random FPL teams, and runs each of the strategies against them for every season
+func runSimulation(args []string) {
+
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	dataDir := flags.String("data", "", "Data directory of the vaastav dataset to use instead of the database")
+	seasonList := flags.String("seasons", "", "Comma separated seasons to simulate, e.g. 2018-19,2019-20 (default all)")
+	strategyList := flags.String("strategies", "", "Comma separated strategies to run, e.g. captaincy,chips (default all)")
+	rulesFile := flags.String("rules", "", "JSON file overriding the FPL rules of every season, e.g. {\"budget\": 1050}")
+	workers := flags.Int("workers", 0, "Number of teams simulated at once, and database connections opened "+
+		"(default max_open_conns of -db-config, or "+strconv.Itoa(database.DefaultMaxOpenConns)+" with a database, "+
+		"or one per CPU with -data)")
+	seed := flags.Int64("seed", 0, "Seed for generating the random teams, to repeat an earlier run (default random)")
+	runsDir := flags.String("runs", internal.RunsDir, "Directory to create the run's results directory in")
+	dbFlags := registerDatabaseFlags(flags)
+	_ = flags.Parse(args)
+
+	manifest := internal.Manifest{Started: time.Now(), Teams: internal.MaxQueries, CodeVersion: internal.CodeVersion()}
 	resolver := internal.NewResolver()
-	resolver.ResolveDatabase()
-	resolver.ResolveCache()
+	resolver.Workers = *workers
+	seasons := parseList(*seasonList)
 
-	// Set the seed used for generating random numbers
-	rand.Seed(time.Now().UnixNano())
+	// Create the strategies to run, which is every strategy unless specific strategies have been requested
+	strategies, err := resolver.NewStrategies(parseList(*strategyList))
+	if err != nil {
+		log.Fatalf("Error: %v\n", err)
+	}
 
-	// Data channels used to store simulation simulation_results
-	resultsCh := make(chan []database.PlayerInfo, internal.MaxQueries)
-	errCh := make(chan error, internal.MaxQueries)
+	// Load the player data into memory when a dataset has been given, rather than connecting to the database
+	if *dataDir != "" {
+		if len(seasons) == 0 {
+			if seasons, err = database.ListSeasons(*dataDir); err != nil {
+				log.Fatalf("Error: %v\n", err)
+			}
+		}
+		repo, err := database.LoadMemoryRepository(*dataDir, seasons)
+		if err != nil {
+			log.Fatalf("Error: %v\n", err)
+		}
+		resolver.Database = repo
+		manifest.DataSource = internal.DataSource{Kind: "csv", Location: *dataDir}
+	} else {
+		config, err := dbFlags.config()
+		if err != nil {
+			log.Fatalf("Error: %v\n", err)
+		}
+		if *workers > 0 {
+			config.MaxOpenConns = *workers
+		}
+		if _, err := resolver.ResolveDatabase(config); err != nil {
+			log.Fatalf("Error: %v\n", err)
+		}
+		manifest.DataSource = internal.DataSource{Kind: config.Driver, Location: config.Location()}
+	}
 
-	// Simulate the teams used to feed into the different FPL strategies
-	log.Printf("-> Simulating 10,000 random FPL teams...\t")
-	resolver.GenerateTeams(resultsCh, errCh)
+	// Run every season available, unless specific seasons have been requested
+	if len(seasons) == 0 {
+		if seasons, err = resolver.Database.Seasons(); err != nil {
+			log.Fatalf("Error: %v\n", err)
+		}
+	}
 
-	// Run the cost variation strategy
-	log.Printf("-> Running Cost Variation strategy...\t")
-	if err := resolver.RunCostVariationStrategy(resultsCh); err != nil {
-		log.Printf("Error: %v\n", err)
+	// Apply any what-if rules on top of the rules of each season
+	if *rulesFile != "" {
+		resolver.Rules = make(map[string]internal.Rules)
+		for _, season := range seasons {
+			rules, err := internal.ReadRules(*rulesFile, season, resolver.Database)
+			if err != nil {
+				log.Fatalf("Error: %v\n", err)
+			}
+			resolver.Rules[season] = rules
+		}
 	}
 
-	//Run the cost distribution strategy
-	log.Printf("-> Running Cost Distribution strategy...\t")
-	if err := resolver.RunDistributionStrategy(resultsCh); err != nil {
-		log.Printf("Error: %v\n", err)
+	// Set the seed used for generating the random teams, picking one unless a seed has been given
+	resolver.Seed = time.Now().UnixNano()
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			resolver.Seed = *seed
+		}
+	})
+	log.Printf("-> Generating the teams with seed %v\t", resolver.Seed)
+
+	// Record how the run is made, so it can be understood and repeated later
+	if manifest.DataSource.Fingerprint, err = resolver.DataFingerprint(seasons); err != nil {
+		log.Fatalf("Error: %v\n", err)
+	}
+	manifest.Seed = resolver.Seed
+	manifest.Workers = resolver.ResolveWorkers().Workers()
+	manifest.Seasons = seasons
+	manifest.Rules = make(map[string]internal.Rules)
+	for _, season := range seasons {
+		manifest.Rules[season] = resolver.ResolveRules(season)
+	}
+	for _, strategy := range strategies {
+		strategyRun := internal.StrategyRun{Name: strategy.Name(), Parameters: strategy.Parameters()}
+		for _, resultsTable := range strategy.Tables() {
+			strategyRun.Files = append(strategyRun.Files, resultsTable.File)
+		}
+		manifest.Strategies = append(manifest.Strategies, strategyRun)
+	}
+
+	// Stop the run when it is interrupted, once the teams being simulated have finished
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		<-interrupts
+		log.Printf("-> Interrupted, stopping the run...\t")
+		cancel()
+	}()
+
+	// Create the run's own directory, along with its results files. The rows of each season are written as soon as
+	// the season has finished, rather than holding every season's rows in memory.
+	var runDir string
+	if manifest.ID, runDir, err = internal.NewRunDir(*runsDir, manifest.Started); err != nil {
+		log.Fatalf("Error: %v\n", err)
+	}
+	createFile := func(file, header string) string {
+		filePath := filepath.Join(runDir, file)
+		if err := internal.WriteResultsFile(filePath, header, nil); err != nil {
+			log.Fatalf("Error: %v\n", err)
+		}
+		manifest.Files = append(manifest.Files, file)
+		return filePath
+	}
+	strategyFiles := make([][]string, len(strategies))
+	for key, strategy := range strategies {
+		for _, resultsTable := range strategy.Tables() {
+			strategyFiles[key] = append(strategyFiles[key], createFile(resultsTable.File, resultsTable.Header))
+		}
+	}
+	calendarFile := createFile(internal.FixtureCalendarFile, internal.FixtureCalendarHeader)
+	samplesFile := createFile(internal.SamplesFile, internal.SamplesHeader)
+	appendRows := func(filePath string, rows []string) {
+		if err := internal.AppendResults(filePath, rows); err != nil {
+			log.Fatalf("Error: %v\n", err)
+		}
 	}
 
-	// Close the channels and process any errors
-	defer close(resultsCh)
-	close(errCh)
-	for err := range errCh {
-		log.Printf("Error: %v\n", err)
+	for _, season := range seasons {
+
+		// Load the points scored by every player in every game week
+		if _, err := resolver.ResolvePoints(season); err != nil {
+			log.Fatalf("Error: %v\n", err)
+		}
+
+		// Load the fixtures of every club, flagging the double and blank game weeks
+		calendar, err := resolver.ResolveCalendar(season)
+		if err != nil {
+			log.Fatalf("Error: %v\n", err)
+		}
+		if results, err := internal.CalendarRows(season, calendar); err != nil {
+			log.Printf("Error: %v\n", err)
+		} else {
+			appendRows(calendarFile, results)
+		}
+
+		// Simulate the teams used to feed into the different FPL strategies
+		log.Printf("-> [%v] Simulating 10,000 random FPL teams...\t", season)
+		started := time.Now()
+		pool, report, err := resolver.GenerateTeams(ctx, season)
+		if err != nil {
+			log.Fatalf("Error: %v\n", err)
+		}
+		manifest.Time(season, "generate-teams", started)
+		log.Printf("-> [%v] %v of %v teams needed repairs to follow the squad rules (%v picked again, %v failed attempts)\t",
+			season, report.Repaired, report.Teams, report.Resampled, report.Failed)
+
+		// Run each strategy against the same simulated teams
+		for key, strategy := range strategies {
+			log.Printf("-> [%v] Running %v strategy...\t", season, strategy.Name())
+			started := time.Now()
+			result, err := resolver.RunStrategy(ctx, strategy, pool)
+			manifest.Time(season, strategy.Name(), started)
+			if ctx.Err() != nil {
+				log.Fatalf("Error: %v\n", err)
+			} else if err != nil {
+				log.Printf("Error: %v\n", err)
+				continue
+			}
+
+			// Record any teams the strategy failed to evaluate, which are missing from its results
+			if result.Dropped > 0 {
+				log.Printf("-> [%v] %v of %v teams failed the %v strategy and were left out of its results: %v\t",
+					season, result.Dropped, pool.Size(), strategy.Name(), result.Errors[0])
+				if manifest.Strategies[key].Dropped == nil {
+					manifest.Strategies[key].Dropped = make(map[string]int)
+				}
+				manifest.Strategies[key].Dropped[season] = result.Dropped
+			}
+
+			for table := range result.Rows {
+				appendRows(strategyFiles[key][table], result.Rows[table])
+			}
+			appendRows(samplesFile, internal.SampleRows(result.Samples))
+		}
+	}
+
+	// Mark the run as complete by writing its manifest alongside the results
+	manifest.Finish()
+	if err := internal.WriteManifest(runDir, manifest); err != nil {
+		log.Fatalf("Error: %v\n", err)
+	}
+	log.Printf("-> Results written to %v\t", runDir)
+}
+
+// listStrategies prints the name and description of every strategy which can be run
+func listStrategies() {
+	resolver := internal.NewResolver()
+	strategies, err := resolver.NewStrategies(nil)
+	if err != nil {
+		log.Fatalf("Error: %v\n", err)
+	}
+	for _, strategy := range strategies {
+		fmt.Printf("%-20v%v\n", strategy.Name(), strategy.Description())
+	}
+}
+
+// parseList splits a comma separated list, such as the seasons or strategies to run
+func parseList(list string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
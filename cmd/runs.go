@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"fpl-strategy-tester/internal"
+	"log"
+	"strings"
+	"time"
+)
+
+// runRuns manages the results directories of past runs, with 'runs list' showing every run
+func runRuns(args []string) {
+	if len(args) == 0 || args[0] != "list" {
+		log.Fatalf("Error: expected 'runs list'")
+	}
+
+	flags := flag.NewFlagSet("runs list", flag.ExitOnError)
+	runsDir := flags.String("runs", internal.RunsDir, "Directory holding the results directory of each run")
+	_ = flags.Parse(args[1:])
+
+	manifests, err := internal.ListRuns(*runsDir)
+	if err != nil {
+		log.Fatalf("Error: %v\n", err)
+	}
+	if len(manifests) == 0 {
+		fmt.Printf("No runs found in %v\n", *runsDir)
+		return
+	}
+
+	fmt.Printf("%-24v%-22v%-10v%-22v%-14v%-14v%v\n",
+		"Run", "Seed", "Duration", "Seasons", "Data", "Code", "Strategies")
+	for _, manifest := range manifests {
+		strategies := make([]string, len(manifest.Strategies))
+		for key, strategy := range manifest.Strategies {
+			strategies[key] = strategy.Name
+		}
+		fmt.Printf("%-24v%-22v%-10v%-22v%-14v%-14v%v\n",
+			manifest.ID,
+			manifest.Seed,
+			(time.Duration(manifest.Seconds) * time.Second).String(),
+			strings.Join(manifest.Seasons, ","),
+			shorten(manifest.DataSource.Fingerprint),
+			shorten(manifest.CodeVersion),
+			strings.Join(strategies, ","),
+		)
+	}
+}
+
+// shorten cuts a hash down to its first 12 characters, keeping any '-dirty' marker
+func shorten(hash string) string {
+	dirty := strings.HasSuffix(hash, "-dirty")
+	hash = strings.TrimSuffix(hash, "-dirty")
+	if len(hash) > 12 {
+		hash = hash[:12]
+	}
+	if dirty {
+		hash += "+"
+	}
+	return hash
+}
@@ -6,5 +6,5 @@ require (
 	github.com/doug-martin/goqu/v9 v9.10.0
 	github.com/go-sql-driver/mysql v1.5.0
 	github.com/icelolly/go-errors v0.2.0
-	github.com/patrickmn/go-cache v2.1.0+incompatible
+	github.com/mattn/go-sqlite3 v1.14.6
 )
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"fmt"
+	"fpl-strategy-tester/internal/database"
+	"strings"
+
+	"github.com/icelolly/go-errors"
+)
+
+/*	CALENDAR:
+	This file of code reports the fixture calendar of each season, flagging the
+	double game weeks, where a club plays twice, and the blank game weeks, where a club
+	doesn't play at all.
+*/
+
+// Results file for the fixture calendar, along with its column headers
+const FixtureCalendarFile = "fixture_calendar.csv"
+const FixtureCalendarHeader = "Season, " +
+	"GW, " +
+	"Fixtures, " +
+	"Double, " +
+	"Blank, " +
+	"Double Teams, " +
+	"Blank Teams\n"
+
+// CalendarRows returns a row for each game week played in the season's calendar, labelled with the season.
+// The clubs playing twice or not at all are listed by ID, separated by spaces.
+func CalendarRows(season string, calendar *database.FixtureCalendar) ([]string, error) {
+	rows := make([]string, 0, calendar.Gameweeks())
+	for _, gw := range calendar.PlayedGameweeks() {
+		fixtures, err := calendar.Fixtures(gw)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		doubleTeams, err := calendar.DoubleTeams(gw)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		blankTeams, err := calendar.BlankTeams(gw)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		rows = append(rows, fmt.Sprintf("%v, %v, %v, %v, %v, %v, %v",
+			season, gw, len(fixtures), len(doubleTeams) > 0, len(blankTeams) > 0,
+			strings.Trim(fmt.Sprint(doubleTeams), "[]"), strings.Trim(fmt.Sprint(blankTeams), "[]"),
+		))
+	}
+	return rows, nil
+}
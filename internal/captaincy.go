@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"fpl-strategy-tester/internal/database"
+	"sort"
+)
+
+/*	CAPTAINCY:
+	This file of code manages the choice of captain and vice-captain for each game week.
+	The captain's points are multiplied, or the vice-captain's if the captain doesn't play,
+	so the captaincy policy used can make a large difference to a team's points.
+*/
+
+// formGameweeks is the number of previous game weeks used to measure a player's form
+const formGameweeks = 5
+
+// CaptaincyPolicy ranks the starters of a lineup for a game week, with the highest ranked player picked
+// as captain, and the next as vice-captain. Players with the same rank are split by price.
+type CaptaincyPolicy struct {
+	Name string
+	Rank func(player database.PlayerInfo, gw int) float64
+}
+
+// Captains are the captain and vice-captain of a lineup, and the multiplier applied to their points
+type Captains struct {
+	Captain     database.PlayerInfo
+	ViceCaptain database.PlayerInfo
+	Multiplier  int
+}
+
+// CaptaincyPolicies returns every captaincy policy, to be compared against each other
+func (r *Resolver) CaptaincyPolicies() []CaptaincyPolicy {
+	return []CaptaincyPolicy{
+		r.MostExpensiveCaptain(),
+		r.FormCaptain(),
+		r.HomeCaptain(),
+		r.HindsightCaptain(),
+	}
+}
+
+// MostExpensiveCaptain captains the most expensive starter
+func (r *Resolver) MostExpensiveCaptain() CaptaincyPolicy {
+	return CaptaincyPolicy{
+		Name: "Most Expensive",
+		Rank: func(player database.PlayerInfo, gw int) float64 {
+			return float64(player.Price)
+		},
+	}
+}
+
+// FormCaptain captains the starter who has scored the most points over the previous few game weeks
+func (r *Resolver) FormCaptain() CaptaincyPolicy {
+	return CaptaincyPolicy{
+		Name: "Best Form",
+		Rank: func(player database.PlayerInfo, gw int) float64 {
+			return float64(r.pointsScored(player, gw-formGameweeks, gw-1))
+		},
+	}
+}
+
+// HomeCaptain captains the most expensive starter who is playing at home
+func (r *Resolver) HomeCaptain() CaptaincyPolicy {
+	return CaptaincyPolicy{
+		Name: "Home Fixture",
+		Rank: func(player database.PlayerInfo, gw int) float64 {
+			points, ok := r.Points[player.Season]
+			if !ok {
+				return 0
+			}
+			if home, err := points.Home(player.ID, gw); err != nil || !home {
+				return 0
+			}
+			return 1
+		},
+	}
+}
+
+// HindsightCaptain captains the starter who went on to score the most points in the game week.
+// It can't be used in practice, but gives an upper bound for the other policies.
+func (r *Resolver) HindsightCaptain() CaptaincyPolicy {
+	return CaptaincyPolicy{
+		Name: "Hindsight",
+		Rank: func(player database.PlayerInfo, gw int) float64 {
+			return float64(r.pointsScored(player, gw, gw))
+		},
+	}
+}
+
+// PickCaptains picks the captain and vice-captain from the lineup's starters using the captaincy policy
+func PickCaptains(lineup Lineup, gw int, policy CaptaincyPolicy, multiplier int) Captains {
+	starters := append([]database.PlayerInfo{}, lineup.Starters...)
+	sort.SliceStable(starters, func(i, j int) bool {
+		rankI, rankJ := policy.Rank(starters[i], gw), policy.Rank(starters[j], gw)
+		if rankI != rankJ {
+			return rankI > rankJ
+		}
+		return starters[i].Price > starters[j].Price
+	})
+
+	captains := Captains{Multiplier: multiplier}
+	if len(starters) > 0 {
+		captains.Captain = starters[0]
+	}
+	if len(starters) > 1 {
+		captains.ViceCaptain = starters[1]
+	}
+	return captains
+}
+
+// pointsScored returns the points the player scored between the two game weeks, inclusive, treating any
+// missing data as no points
+func (r *Resolver) pointsScored(player database.PlayerInfo, fromGW, toGW int) int {
+	points, ok := r.Points[player.Season]
+	if !ok {
+		return 0
+	}
+	total, err := points.Total(player.ID, fromGW, toGW)
+	if err != nil {
+		return 0
+	}
+	return total
+}
@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/icelolly/go-errors"
+)
+
+/*	CAPTAINCY STRATEGY:
+	This file of code compares the captaincy policies against each other.
+	Every policy captains the same simulated teams, so any difference in points
+	comes from the choice of captain alone.
+*/
+
+func init() {
+	RegisterStrategy(captaincyName, func(r *Resolver) Strategy {
+		return &CaptaincyStrategy{resolver: r, policies: r.CaptaincyPolicies()}
+	})
+}
+
+// Name used to pick the strategy from the command line
+const captaincyName = "captaincy"
+
+// Results file for the captaincy strategy, along with its column headers
+const CaptaincyFile = "captaincy.csv"
+const CaptaincyHeader = "Season, " +
+	"Captaincy Policy, " +
+	"Average Points, " +
+	"5th Percentile, " +
+	"25th Percentile, " +
+	"50th Percentile, " +
+	"75th Percentile, " +
+	"95th Percentile\n"
+
+// CaptaincyStrategy scores each simulated team under every captaincy policy
+type CaptaincyStrategy struct {
+	resolver *Resolver
+	policies []CaptaincyPolicy
+}
+
+// Name returns the name used to pick the strategy from the command line
+func (s *CaptaincyStrategy) Name() string {
+	return captaincyName
+}
+
+// Description returns a summary of what the strategy tests
+func (s *CaptaincyStrategy) Description() string {
+	return "Points of the same teams under each captaincy policy"
+}
+
+// Parameters returns the captaincy policies compared, and the number of game weeks used to measure form
+func (s *CaptaincyStrategy) Parameters() map[string]string {
+	names := make([]string, len(s.policies))
+	for key, policy := range s.policies {
+		names[key] = policy.Name
+	}
+	return map[string]string{
+		"policies":       strings.Join(names, ", "),
+		"form_gameweeks": strconv.Itoa(formGameweeks),
+	}
+}
+
+// Tables returns the results file of the strategy
+func (s *CaptaincyStrategy) Tables() []ResultsTable {
+	return []ResultsTable{{File: CaptaincyFile, Header: CaptaincyHeader, Comparable: true}}
+}
+
+// Evaluate calculates the overall team points under each policy, in the same order as the policies
+func (s *CaptaincyStrategy) Evaluate(team Squad) (interface{}, error) {
+	teamPoints := make([]int, len(s.policies))
+	for key, policy := range s.policies {
+		points, err := s.resolver.CalculateTeamPoints(team, policy)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		teamPoints[key] = points
+	}
+	return teamPoints, nil
+}
+
+// Aggregate returns a results row for each policy, labelled with the season
+func (s *CaptaincyStrategy) Aggregate(season string, results []interface{}) ([][]string, error) {
+
+	// Collect the points of every team by policy
+	policyResults, _ := groupByColumn(results, len(s.policies))
+
+	// Calculate the average and percentiles of each policy
+	rows := make([]string, len(s.policies))
+	for key, policy := range s.policies {
+		pointsSum := 0
+		for _, points := range policyResults[key] {
+			pointsSum += points
+		}
+		averagePoints := 0.0
+		if len(policyResults[key]) > 0 {
+			averagePoints = float64(pointsSum) / float64(len(policyResults[key]))
+		}
+
+		percentile := findPercentiles(policyResults[key])
+		strData := strings.Trim(strings.Replace(fmt.Sprint(percentile), " ", ", ", -1), "[]")
+		rows[key] = fmt.Sprintf("%v, %v, %.2f, %v", season, policy.Name, averagePoints, strData)
+	}
+
+	return [][]string{rows}, nil
+}
+
+// Samples returns the points of the teams under each policy
+func (s *CaptaincyStrategy) Samples(season string, results []interface{}) []Sample {
+	policyResults, teams := groupByColumn(results, len(s.policies))
+	samples := make([]Sample, len(s.policies))
+	for key, policy := range s.policies {
+		samples[key] = Sample{File: CaptaincyFile, Season: season, Group: policy.Name, Teams: teams[key], Values: policyResults[key]}
+	}
+	return samples
+}
+
+// groupByColumn takes results holding a value for each policy or manager, and returns the values of every team
+// by policy or manager, along with the index of the team behind each value
+func groupByColumn(results []interface{}, columns int) ([][]int, [][]int) {
+	grouped := make([][]int, columns)
+	teams := make([][]int, columns)
+	for teamNumber, result := range results {
+		if result == nil {
+			continue
+		}
+		for key, value := range result.([]int) {
+			grouped[key] = append(grouped[key], value)
+			teams[key] = append(teams[key], teamNumber)
+		}
+	}
+	return grouped, teams
+}
@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/icelolly/go-errors"
+)
+
+/*	CHIP STRATEGY:
+	This file of code compares the timing policies of each chip.
+	Each simulated team plays its season once without any chips, then once for each
+	timing policy, and the difference in points shows what the chip was worth.
+*/
+
+func init() {
+	RegisterStrategy(chipName, func(r *Resolver) Strategy { return NewChipStrategy(r) })
+}
+
+// Name used to pick the strategy from the command line
+const chipName = "chips"
+
+// Results file for the chip strategy, along with its column headers
+const ChipFile = "chips.csv"
+const ChipHeader = "Season, " +
+	"Chip Strategy, " +
+	"5th Percentile ," +
+	"25th Percentile, " +
+	"50th Percentile, " +
+	"75th Percentile, " +
+	"95th Percentile\n"
+
+// ChipStrategy plays each simulated team's season with every chip timing policy, and compares the points
+// against playing no chips
+type ChipStrategy struct {
+	resolver *Resolver
+	baseline Manager
+	managers []Manager
+}
+
+// NewChipStrategy creates the strategy with a manager for each chip timing policy
+func NewChipStrategy(r *Resolver) *ChipStrategy {
+	captaincy := r.MostExpensiveCaptain()
+	managers := make([]Manager, 0)
+	for _, timing := range r.ChipTimings() {
+		managers = append(managers, NewChipManager(r, timing, captaincy))
+	}
+	return &ChipStrategy{
+		resolver: r,
+		baseline: SetAndForgetManager{Captaincy: captaincy},
+		managers: managers,
+	}
+}
+
+// Name returns the name used to pick the strategy from the command line
+func (s *ChipStrategy) Name() string {
+	return chipName
+}
+
+// Description returns a summary of what the strategy tests
+func (s *ChipStrategy) Description() string {
+	return "Points gained by each chip timing policy over playing no chips"
+}
+
+// Parameters returns the chip timing policies compared, their captaincy policy, and the shortest gap between
+// game weeks taken to be an international break
+func (s *ChipStrategy) Parameters() map[string]string {
+	return map[string]string{
+		"timings":                  managerNames(s.managers),
+		"captaincy":                s.resolver.MostExpensiveCaptain().Name,
+		"international_break_days": strconv.Itoa(internationalBreakDays),
+	}
+}
+
+// Tables returns the results file of the strategy
+func (s *ChipStrategy) Tables() []ResultsTable {
+	return []ResultsTable{{File: ChipFile, Header: ChipHeader, Comparable: true}}
+}
+
+// Evaluate calculates the points gained by playing the chip under each policy, in the same order as the managers
+func (s *ChipStrategy) Evaluate(team Squad) (interface{}, error) {
+	baselinePoints, err := s.resolver.seasonPoints(team, s.baseline)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	pointsGained := make([]int, len(s.managers))
+	for key, manager := range s.managers {
+		points, err := s.resolver.seasonPoints(team, manager)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		pointsGained[key] = points - baselinePoints
+	}
+	return pointsGained, nil
+}
+
+// Aggregate returns a results row of the points gained for each policy, labelled with the season
+func (s *ChipStrategy) Aggregate(season string, results []interface{}) ([][]string, error) {
+
+	// Collect the points gained by every team, by policy
+	managerResults, _ := groupByColumn(results, len(s.managers))
+
+	// Calculate the percentiles for each policy
+	percentiles := make([]string, len(s.managers))
+	for key, manager := range s.managers {
+		percentile := findPercentiles(managerResults[key])
+		strData := strings.Trim(strings.Replace(fmt.Sprint(percentile), " ", ", ", -1), "[]")
+		percentiles[key] = fmt.Sprintf("%v, %v, %v", season, manager.Name(), strData)
+	}
+
+	return [][]string{percentiles}, nil
+}
+
+// Samples returns the points gained by the teams under each policy
+func (s *ChipStrategy) Samples(season string, results []interface{}) []Sample {
+	managerResults, teams := groupByColumn(results, len(s.managers))
+	samples := make([]Sample, len(s.managers))
+	for key, manager := range s.managers {
+		samples[key] = Sample{File: ChipFile, Season: season, Group: manager.Name(), Teams: teams[key], Values: managerResults[key]}
+	}
+	return samples
+}
+
+// seasonPoints returns the total points of the team's season with the manager
+func (r *Resolver) seasonPoints(team Squad, manager Manager) (int, error) {
+	results, err := r.SimulateSeason(team, manager)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[len(results)-1].TotalPoints, nil
+}
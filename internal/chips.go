@@ -0,0 +1,248 @@
+package internal
+
+import (
+	"fpl-strategy-tester/internal/database"
+
+	"github.com/icelolly/go-errors"
+)
+
+/*	CHIPS:
+	This file of code manages when each chip is played during a season.
+	Each timing policy picks the game week to play its chip, based on the season's
+	fixture calendar and the fixtures of the squad's players.
+*/
+
+// internationalBreakDays is the shortest gap between game weeks which is taken to be an international break
+const internationalBreakDays = 10
+
+// defaultFirstBreak is the game week the first international break usually follows, used when the dataset
+// doesn't record kickoff times
+const defaultFirstBreak = 4
+
+// ChipTiming picks the game week to play a chip in, with Gameweek returning zero when the chip shouldn't be
+// played. Captain picks the captain for the week the chip is played, and is only set when the chip depends on it.
+type ChipTiming struct {
+	Name     string
+	Chip     Chip
+	Gameweek func(state SeasonState) int
+	Captain  func(state SeasonState) database.PlayerInfo
+}
+
+// ChipTimings returns every chip timing policy, to be compared against playing no chips
+func (r *Resolver) ChipTimings() []ChipTiming {
+	return []ChipTiming{
+		r.BenchBoostFirstDouble(),
+		r.TripleCaptainPremiumForward(),
+		r.WildcardAfterBreak(),
+		r.FreeHitBiggestBlank(),
+	}
+}
+
+// BenchBoostFirstDouble plays the Bench Boost in the season's first double game week. Without any double game
+// weeks, it is played in the game week the squad has the most fixtures.
+func (r *Resolver) BenchBoostFirstDouble() ChipTiming {
+	return ChipTiming{
+		Name: "Bench Boost in First Double",
+		Chip: BenchBoost,
+		Gameweek: func(state SeasonState) int {
+			if calendar, ok := r.Calendars[state.Rules.Season]; ok {
+				for gw := 1; gw <= calendar.Gameweeks(); gw++ {
+					if calendar.IsDouble(gw) {
+						return gw
+					}
+				}
+			}
+			gw, _ := r.bestGameweek(state.Squad.Players(), func(fixtures, gw int) int {
+				return fixtures
+			})
+			return gw
+		},
+	}
+}
+
+// TripleCaptainPremiumForward plays the Triple Captain on the squad's most expensive forward, in the game week
+// they have the most fixtures, preferring a home fixture
+func (r *Resolver) TripleCaptainPremiumForward() ChipTiming {
+	return ChipTiming{
+		Name: "Triple Captain Premium Forward",
+		Chip: TripleCaptain,
+		Gameweek: func(state SeasonState) int {
+			forward := premiumForward(state.Squad)
+			gw, _ := r.bestGameweek([]database.PlayerInfo{forward}, func(fixtures, gw int) int {
+				if points, ok := r.Points[forward.Season]; ok {
+					if home, err := points.Home(forward.ID, gw); err == nil && home {
+						return fixtures*2 + 1
+					}
+				}
+				return fixtures * 2
+			})
+			return gw
+		},
+		Captain: func(state SeasonState) database.PlayerInfo {
+			return premiumForward(state.Squad)
+		},
+	}
+}
+
+// WildcardAfterBreak plays the Wildcard in the game week after the first international break, once the first
+// few weeks of form are known
+func (r *Resolver) WildcardAfterBreak() ChipTiming {
+	return ChipTiming{
+		Name: "Wildcard After First Break",
+		Chip: Wildcard,
+		Gameweek: func(state SeasonState) int {
+			return r.firstInternationalBreak(state.Rules.Season) + 1
+		},
+	}
+}
+
+// FreeHitBiggestBlank plays the Free Hit in the season's biggest blank game week, where the most clubs have no
+// fixture. Only game weeks with fixtures are considered, and it isn't played when the season has no blank game
+// weeks.
+func (r *Resolver) FreeHitBiggestBlank() ChipTiming {
+	return ChipTiming{
+		Name: "Free Hit in Biggest Blank",
+		Chip: FreeHit,
+		Gameweek: func(state SeasonState) int {
+			calendar, ok := r.Calendars[state.Rules.Season]
+			if !ok {
+				return 0
+			}
+			biggestGW, biggestBlank := 0, 0
+			for _, gw := range calendar.PlayedGameweeks() {
+				if teams, err := calendar.BlankTeams(gw); err == nil && len(teams) > biggestBlank {
+					biggestGW, biggestBlank = gw, len(teams)
+				}
+			}
+			return biggestGW
+		},
+	}
+}
+
+// ChipManager keeps the squad picked at the start of the season, like the SetAndForgetManager, apart from
+// playing a single chip when its timing policy says to. Wildcard and Free Hit transfers bring in the players
+// in the best form.
+type ChipManager struct {
+	timing    ChipTiming
+	captaincy CaptaincyPolicy
+	transfers TransferManager
+}
+
+// NewChipManager creates a manager which plays the chip of the timing policy
+func NewChipManager(resolver *Resolver, timing ChipTiming, captaincy CaptaincyPolicy) ChipManager {
+	return ChipManager{
+		timing:    timing,
+		captaincy: captaincy,
+		transfers: NewTransferManager(resolver, captaincy, false),
+	}
+}
+
+// Name returns the name of the manager, used to label their results
+func (m ChipManager) Name() string {
+	return m.timing.Name
+}
+
+// Decide plays the chip in its game week, then picks the lineup and captains
+func (m ChipManager) Decide(state SeasonState) (Decisions, error) {
+	decisions := Decisions{}
+	squad := state.Squad
+
+	if state.Chips[m.timing.Chip] > 0 && state.GW == m.timing.Gameweek(state) {
+		decisions.Chip = m.timing.Chip
+		if decisions.Chip == Wildcard || decisions.Chip == FreeHit {
+			transfers, err := m.transfers.pickTransfers(state, decisions.Chip)
+			if err != nil {
+				return Decisions{}, errors.Wrap(err)
+			}
+			decisions.Transfers = transfers
+			squad = applyTransfers(squad, transfers)
+		}
+	}
+
+	decisions.Lineup = PickLineup(squad, state.Rules, RankByPrice)
+	captains := PickCaptains(decisions.Lineup, state.GW, m.captaincy, state.Rules.CaptainMultiplier)
+	decisions.Captain, decisions.ViceCaptain = captains.Captain, captains.ViceCaptain
+
+	// Move the armband onto the player the chip was played for, if they're starting
+	if decisions.Chip != "" && m.timing.Captain != nil {
+		captain := m.timing.Captain(state)
+		for _, starter := range decisions.Lineup.Starters {
+			if starter.ID == captain.ID && captain.ID != decisions.Captain.ID {
+				decisions.Captain, decisions.ViceCaptain = captain, decisions.Captain
+			}
+		}
+	}
+
+	return decisions, nil
+}
+
+// bestGameweek returns the earliest game week with the highest score, along with its score. Each game week is
+// scored by adding up the score of each player's fixtures.
+func (r *Resolver) bestGameweek(players []database.PlayerInfo, score func(fixtures, gw int) int) (int, int) {
+	if len(players) == 0 {
+		return 0, 0
+	}
+	points, ok := r.Points[players[0].Season]
+	if !ok {
+		return 0, 0
+	}
+
+	bestGW, bestScore := 0, 0
+	for gw := 1; gw <= points.Gameweeks(); gw++ {
+		gwScore := 0
+		for _, player := range players {
+			gwScore += score(r.fixtureCount(player, gw), gw)
+		}
+		if bestGW == 0 || gwScore > bestScore {
+			bestGW, bestScore = gw, gwScore
+		}
+	}
+	return bestGW, bestScore
+}
+
+// firstInternationalBreak returns the game week before the first long gap between game weeks in the season
+func (r *Resolver) firstInternationalBreak(season string) int {
+	calendar, ok := r.Calendars[season]
+	if !ok {
+		return defaultFirstBreak
+	}
+	for gw := 1; gw < calendar.Gameweeks(); gw++ {
+		kickoff, err := calendar.Kickoff(gw)
+		if err != nil || kickoff.IsZero() {
+			return defaultFirstBreak
+		}
+		nextKickoff, err := calendar.Kickoff(gw + 1)
+		if err != nil || nextKickoff.IsZero() {
+			return defaultFirstBreak
+		}
+		if nextKickoff.Sub(kickoff).Hours() >= internationalBreakDays*24 {
+			return gw
+		}
+	}
+	return defaultFirstBreak
+}
+
+// fixtureCount returns the number of fixtures the player had in the game week, treating any missing data as
+// no fixtures
+func (r *Resolver) fixtureCount(player database.PlayerInfo, gw int) int {
+	points, ok := r.Points[player.Season]
+	if !ok {
+		return 0
+	}
+	fixtures, err := points.Fixtures(player.ID, gw)
+	if err != nil {
+		return 0
+	}
+	return fixtures
+}
+
+// premiumForward returns the squad's most expensive forward
+func premiumForward(squad Squad) database.PlayerInfo {
+	forward := database.PlayerInfo{}
+	for _, player := range squad.Position(database.Forward) {
+		if player.Price > forward.Price {
+			forward = player
+		}
+	}
+	return forward
+}
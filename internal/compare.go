@@ -0,0 +1,420 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/icelolly/go-errors"
+)
+
+/*	COMPARE:
+	This file of code lines up the results of two runs, row by row, so the effect of a
+	change can be measured. Each row's samples, the values of every team behind it, are
+	tested to flag which differences are bigger than chance. Runs simulating the same
+	teams are paired team by team, and any others are tested with Welch's t-test. The
+	p-values are corrected for the number of rows tested.
+*/
+
+// Results file holding the samples behind the rows of each comparable results table, along with its column
+// headers. The values of each sample are separated by spaces, each following the index of its team and a colon.
+const SamplesFile = "samples.csv"
+const SamplesHeader = "File, Season, Group, Team:Values\n"
+
+// Tests used to compare the samples of a row
+const PairedTest = "paired t-test"
+const WelchTest = "Welch's t-test"
+
+// ValueDiff is the difference between the two runs in a single column of a row
+type ValueDiff struct {
+	Column string
+	A      float64
+	B      float64
+}
+
+// Diff returns how much the value changed from the first run to the second
+func (v ValueDiff) Diff() float64 {
+	return v.B - v.A
+}
+
+// RowComparison lines up a row of a results table in the two runs. Rows found in only one run have no values.
+// The row is tested when both runs have its samples, and the p-value is the chance of a difference in the mean
+// at least as big if nothing had changed. The adjusted p-value corrects it for every other row tested.
+type RowComparison struct {
+	File      string
+	Season    string
+	Group     string
+	InA       bool
+	InB       bool
+	Values    []ValueDiff
+	Tested    bool
+	Test      string
+	T         float64
+	PValue    float64
+	AdjustedP float64
+}
+
+// Changed reports whether any of the row's values differ between the runs
+func (r RowComparison) Changed() bool {
+	if r.InA != r.InB {
+		return true
+	}
+	for _, value := range r.Values {
+		if value.Diff() != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Significant reports whether the difference in the row's samples is unlikely to be down to chance, once
+// corrected for the number of rows tested
+func (r RowComparison) Significant(alpha float64) bool {
+	return r.Tested && r.AdjustedP < alpha
+}
+
+// Comparison is every difference found between two runs, along with the number of rows tested
+type Comparison struct {
+	A        Manifest
+	B        Manifest
+	Settings []string
+	Rows     []RowComparison
+	Tests    int
+}
+
+// SampleRows returns the rows of the samples file, each labelled with the file, season and group of its row
+func SampleRows(samples []Sample) []string {
+	rows := make([]string, len(samples))
+	for key, sample := range samples {
+		values := make([]string, len(sample.Values))
+		for i, value := range sample.Values {
+			values[i] = fmt.Sprintf("%v:%v", sample.Teams[i], value)
+		}
+		rows[key] = fmt.Sprintf("%v, %v, %v, %v", sample.File, sample.Season, sample.Group, strings.Join(values, " "))
+	}
+	return rows
+}
+
+// CompareRuns lines up the comparable results tables of the two run directories, and tests each row's samples.
+// When both runs simulated the same teams, the samples are paired by team.
+func CompareRuns(dirA, dirB string, tables []ResultsTable) (Comparison, error) {
+	comparison := Comparison{}
+	var err error
+	if comparison.A, err = ReadManifest(dirA); err != nil {
+		return Comparison{}, errors.Wrap(err)
+	}
+	if comparison.B, err = ReadManifest(dirB); err != nil {
+		return Comparison{}, errors.Wrap(err)
+	}
+	comparison.Settings = compareManifests(comparison.A, comparison.B)
+
+	samplesA, err := readSamples(dirA)
+	if err != nil {
+		return Comparison{}, errors.Wrap(err)
+	}
+	samplesB, err := readSamples(dirB)
+	if err != nil {
+		return Comparison{}, errors.Wrap(err)
+	}
+
+	for _, table := range tables {
+		if !table.Comparable {
+			continue
+		}
+		header, rowsA, orderA, err := readResults(dirA, table.File)
+		if err != nil {
+			return Comparison{}, errors.Wrap(err)
+		}
+		_, rowsB, orderB, err := readResults(dirB, table.File)
+		if err != nil {
+			return Comparison{}, errors.Wrap(err)
+		}
+		if rowsA == nil || rowsB == nil {
+			continue
+		}
+
+		// Line up the rows in the order of the first run, followed by any rows only found in the second run
+		order := orderA
+		for _, key := range orderB {
+			if _, ok := rowsA[key]; !ok {
+				order = append(order, key)
+			}
+		}
+
+		for _, key := range order {
+			rowA, inA := rowsA[key]
+			rowB, inB := rowsB[key]
+			row := RowComparison{File: table.File, Season: key[0], Group: key[1], InA: inA, InB: inB}
+			if inA && inB {
+				row.Values = compareValues(header, rowA, rowB)
+			}
+
+			sampleA, sampledA := samplesA[sampleKey{File: table.File, Season: key[0], Group: key[1]}]
+			sampleB, sampledB := samplesB[sampleKey{File: table.File, Season: key[0], Group: key[1]}]
+			if inA && inB && sampledA && sampledB {
+				row.Tested = true
+				if sameTeams(comparison.A, comparison.B, key[0]) && sampleA.Teams != nil && sampleB.Teams != nil {
+					row.Test = PairedTest
+					row.T, row.PValue = PairedTTest(pairByTeam(sampleA, sampleB))
+				} else {
+					row.Test = WelchTest
+					row.T, row.PValue = WelchTTest(sampleA.Values, sampleB.Values)
+				}
+			}
+			comparison.Rows = append(comparison.Rows, row)
+		}
+	}
+
+	// Correct the p-values for the number of rows tested
+	pValues := make([]float64, 0)
+	for _, row := range comparison.Rows {
+		if row.Tested {
+			pValues = append(pValues, row.PValue)
+		}
+	}
+	adjusted := HolmCorrection(pValues)
+	for key := range comparison.Rows {
+		if comparison.Rows[key].Tested {
+			comparison.Rows[key].AdjustedP = adjusted[comparison.Tests]
+			comparison.Tests++
+		}
+	}
+
+	return comparison, nil
+}
+
+// sameTeams reports whether the two runs simulated the same teams in the season, having generated them from the
+// same seed, data and rules with the same code. Runs of unknown code, or with uncommitted changes, are never
+// assumed to match, as their code may have differed.
+func sameTeams(a, b Manifest, season string) bool {
+	return a.Seed == b.Seed &&
+		a.Teams == b.Teams &&
+		a.CodeVersion == b.CodeVersion &&
+		a.CodeVersion != unknownVersion &&
+		!strings.HasSuffix(a.CodeVersion, "-dirty") &&
+		a.DataSource.Fingerprint == b.DataSource.Fingerprint &&
+		reflect.DeepEqual(a.Rules[season], b.Rules[season])
+}
+
+// pairByTeam returns the values of the teams found in both samples, in the same order for each
+func pairByTeam(a, b Sample) ([]int, []int) {
+	valuesB := make(map[int]int)
+	for key, team := range b.Teams {
+		valuesB[team] = b.Values[key]
+	}
+
+	pairedA, pairedB := make([]int, 0), make([]int, 0)
+	for key, team := range a.Teams {
+		if value, ok := valuesB[team]; ok {
+			pairedA = append(pairedA, a.Values[key])
+			pairedB = append(pairedB, value)
+		}
+	}
+	return pairedA, pairedB
+}
+
+// rowKey is the season and group labelling a row of a comparable results table
+type rowKey [2]string
+
+// sampleKey is the results file, season and group of the row a sample is behind
+type sampleKey struct {
+	File   string
+	Season string
+	Group  string
+}
+
+// readResults reads the results table of the run directory, returning its header and each row by its season and
+// group, along with the order of the rows. A run without the table returns no rows.
+func readResults(runDir, file string) ([]string, map[rowKey][]string, []rowKey, error) {
+	lines, err := readLines(filepath.Join(runDir, file))
+	if os.IsNotExist(err) {
+		return nil, nil, nil, nil
+	} else if err != nil {
+		return nil, nil, nil, errors.Wrap(err)
+	}
+	if len(lines) == 0 {
+		return nil, nil, nil, errors.New("Results file has no header: " + file)
+	}
+
+	header := splitRow(lines[0])
+	rows := make(map[rowKey][]string)
+	order := make([]rowKey, 0)
+	for _, line := range lines[1:] {
+		row := splitRow(line)
+		if len(row) < 2 {
+			continue
+		}
+		key := rowKey{row[0], row[1]}
+		if _, ok := rows[key]; !ok {
+			order = append(order, key)
+		}
+		rows[key] = row
+	}
+	return header, rows, order, nil
+}
+
+// readSamples reads the samples file of the run directory. Runs made before samples were kept return none, and
+// samples kept without the index of each team have no teams.
+func readSamples(runDir string) (map[sampleKey]Sample, error) {
+	samples := make(map[sampleKey]Sample)
+	lines, err := readLines(filepath.Join(runDir, SamplesFile))
+	if os.IsNotExist(err) {
+		return samples, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	for key, line := range lines {
+		if key == 0 {
+			continue
+		}
+		fields := strings.SplitN(line, ", ", 4)
+		if len(fields) < 3 {
+			return nil, errors.New("Malformed sample row: " + line)
+		}
+		sample := Sample{File: fields[0], Season: fields[1], Group: fields[2], Teams: make([]int, 0), Values: make([]int, 0)}
+		indexed := true
+		if len(fields) == 4 {
+			for _, field := range strings.Fields(fields[3]) {
+				team, value := "", field
+				if split := strings.SplitN(field, ":", 2); len(split) == 2 {
+					team, value = split[0], split[1]
+				} else {
+					indexed = false
+				}
+
+				parsed, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, errors.Wrap(err)
+				}
+				sample.Values = append(sample.Values, parsed)
+				if team != "" {
+					parsed, err := strconv.Atoi(team)
+					if err != nil {
+						return nil, errors.Wrap(err)
+					}
+					sample.Teams = append(sample.Teams, parsed)
+				}
+			}
+		}
+		if !indexed {
+			sample.Teams = nil
+		}
+		samples[sampleKey{File: sample.File, Season: sample.Season, Group: sample.Group}] = sample
+	}
+	return samples, nil
+}
+
+// compareValues returns the difference in each numeric column of the two rows, after the season and group
+func compareValues(header, rowA, rowB []string) []ValueDiff {
+	values := make([]ValueDiff, 0)
+	for column := 2; column < len(header) && column < len(rowA) && column < len(rowB); column++ {
+		a, errA := strconv.ParseFloat(rowA[column], 64)
+		b, errB := strconv.ParseFloat(rowB[column], 64)
+		if errA != nil || errB != nil {
+			continue
+		}
+		values = append(values, ValueDiff{Column: header[column], A: a, B: b})
+	}
+	return values
+}
+
+// compareManifests returns a description of each setting which differs between the two runs
+func compareManifests(a, b Manifest) []string {
+	settings := make([]string, 0)
+	differs := func(setting string, valueA, valueB interface{}) {
+		if !reflect.DeepEqual(valueA, valueB) {
+			settings = append(settings, fmt.Sprintf("%v: %v -> %v", setting, valueA, valueB))
+		}
+	}
+
+	differs("Seed", a.Seed, b.Seed)
+	differs("Teams", a.Teams, b.Teams)
+	differs("Seasons", a.Seasons, b.Seasons)
+	differs("Data", a.DataSource.Fingerprint, b.DataSource.Fingerprint)
+	differs("Code", a.CodeVersion, b.CodeVersion)
+
+	seasons := make(map[string]bool)
+	for season := range a.Rules {
+		seasons[season] = true
+	}
+	for season := range b.Rules {
+		seasons[season] = true
+	}
+	for _, season := range sortedKeys(seasons) {
+		differs("Rules "+season, a.Rules[season], b.Rules[season])
+	}
+
+	strategiesA := make(map[string]StrategyRun)
+	strategiesB := make(map[string]StrategyRun)
+	names := make(map[string]bool)
+	for _, strategy := range a.Strategies {
+		strategiesA[strategy.Name] = strategy
+		names[strategy.Name] = true
+	}
+	for _, strategy := range b.Strategies {
+		strategiesB[strategy.Name] = strategy
+		names[strategy.Name] = true
+	}
+	for _, name := range sortedKeys(names) {
+		strategyA, inA := strategiesA[name]
+		strategyB, inB := strategiesB[name]
+		if !inA {
+			settings = append(settings, fmt.Sprintf("Strategy %v: only in run B", name))
+		} else if !inB {
+			settings = append(settings, fmt.Sprintf("Strategy %v: only in run A", name))
+		} else {
+			differs("Strategy "+name, strategyA.Parameters, strategyB.Parameters)
+			differs("Strategy "+name+" teams left out", strategyA.Dropped, strategyB.Dropped)
+		}
+	}
+
+	return settings
+}
+
+// sortedKeys returns the keys of the set in alphabetical order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// splitRow splits a row of a results file into its columns
+func splitRow(line string) []string {
+	columns := strings.Split(line, ",")
+	for key, column := range columns {
+		columns[key] = strings.TrimSpace(column)
+	}
+	return columns
+}
+
+// readLines returns every non-empty line of the file. The error opening the file isn't wrapped, so a missing file
+// can be told apart.
+func readLines(filePath string) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return lines, nil
+}
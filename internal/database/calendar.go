@@ -0,0 +1,224 @@
+package database
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/icelolly/go-errors"
+)
+
+// Fixture is a match between two Premier League clubs
+type Fixture struct {
+	ID       int
+	GW       int
+	HomeTeam int
+	AwayTeam int
+	Kickoff  time.Time
+}
+
+// FixtureCalendar holds the fixtures of every club in each game week of a season, so double and blank game weeks
+// can be found.
+type FixtureCalendar struct {
+	fixtures  [][]Fixture
+	teams     []int
+	gameweeks int
+}
+
+// NewFixtureCalendar rebuilds the season's fixtures from the game week data of every player.
+// The game week data only records each player's opponent, so the home club of a fixture is the opponent of its
+// away players, and the away club is the opponent of its home players.
+func NewFixtureCalendar(gwData []PlayerGWInfo) *FixtureCalendar {
+	fixtures := make(map[int]*Fixture)
+	teams := make(map[int]bool)
+	c := &FixtureCalendar{}
+
+	for _, gw := range gwData {
+		if gw.GW > c.gameweeks {
+			c.gameweeks = gw.GW
+		}
+
+		fixture, ok := fixtures[gw.Fixture]
+		if !ok {
+			fixture = &Fixture{ID: gw.Fixture, GW: gw.GW}
+			fixtures[gw.Fixture] = fixture
+		}
+		if wasHome(gw.WasHome) {
+			fixture.AwayTeam = gw.OpponentTeam
+		} else {
+			fixture.HomeTeam = gw.OpponentTeam
+		}
+		if kickoff, err := time.Parse(time.RFC3339, gw.KickoffTime); err == nil {
+			fixture.Kickoff = kickoff
+		}
+		teams[gw.OpponentTeam] = true
+	}
+
+	c.fixtures = make([][]Fixture, c.gameweeks+1)
+	for _, fixture := range fixtures {
+		c.fixtures[fixture.GW] = append(c.fixtures[fixture.GW], *fixture)
+	}
+	for _, gwFixtures := range c.fixtures {
+		sort.Slice(gwFixtures, func(i, j int) bool {
+			return gwFixtures[i].ID < gwFixtures[j].ID
+		})
+	}
+	for team := range teams {
+		c.teams = append(c.teams, team)
+	}
+	sort.Ints(c.teams)
+
+	return c
+}
+
+// Gameweeks returns the number of the last game week in the season
+func (c *FixtureCalendar) Gameweeks() int {
+	return c.gameweeks
+}
+
+// Teams returns the ID of every club playing in the season
+func (c *FixtureCalendar) Teams() []int {
+	return c.teams
+}
+
+// Fixtures returns the fixtures played in the game week
+func (c *FixtureCalendar) Fixtures(gw int) ([]Fixture, error) {
+	if gw < 1 || gw > c.gameweeks {
+		return nil, errors.New("Game week out of range: " + strconv.Itoa(gw))
+	}
+	return c.fixtures[gw], nil
+}
+
+// Played reports whether any fixtures were played in the game week. Gaps in a season's game week numbers, such as
+// GW30 to GW38 of 2019-20 in the dataset, have none.
+func (c *FixtureCalendar) Played(gw int) bool {
+	return gw >= 1 && gw <= c.gameweeks && len(c.fixtures[gw]) > 0
+}
+
+// PlayedGameweeks returns every game week with fixtures, in order
+func (c *FixtureCalendar) PlayedGameweeks() []int {
+	gameweeks := make([]int, 0, c.gameweeks)
+	for gw := 1; gw <= c.gameweeks; gw++ {
+		if c.Played(gw) {
+			gameweeks = append(gameweeks, gw)
+		}
+	}
+	return gameweeks
+}
+
+// TeamFixtures returns the number of fixtures the club played in the game week
+func (c *FixtureCalendar) TeamFixtures(team, gw int) (int, error) {
+	fixtures, err := c.Fixtures(gw)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+
+	count := 0
+	for _, fixture := range fixtures {
+		if fixture.HomeTeam == team || fixture.AwayTeam == team {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// DoubleTeams returns the clubs with more than one fixture in the game week
+func (c *FixtureCalendar) DoubleTeams(gw int) ([]int, error) {
+	return c.teamsWhere(gw, func(fixtures int) bool { return fixtures > 1 })
+}
+
+// BlankTeams returns the clubs with no fixture in the game week. A game week without any fixtures wasn't played,
+// so has no blank clubs.
+func (c *FixtureCalendar) BlankTeams(gw int) ([]int, error) {
+	if _, err := c.Fixtures(gw); err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if !c.Played(gw) {
+		return make([]int, 0), nil
+	}
+	return c.teamsWhere(gw, func(fixtures int) bool { return fixtures == 0 })
+}
+
+// IsDouble reports whether any club has more than one fixture in the game week
+func (c *FixtureCalendar) IsDouble(gw int) bool {
+	teams, err := c.DoubleTeams(gw)
+	return err == nil && len(teams) > 0
+}
+
+// IsBlank reports whether any club has no fixture in the game week
+func (c *FixtureCalendar) IsBlank(gw int) bool {
+	teams, err := c.BlankTeams(gw)
+	return err == nil && len(teams) > 0
+}
+
+// Kickoff returns the kickoff time of the game week's first fixture, which is zero when the dataset doesn't
+// record kickoff times
+func (c *FixtureCalendar) Kickoff(gw int) (time.Time, error) {
+	fixtures, err := c.Fixtures(gw)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err)
+	}
+
+	kickoff := time.Time{}
+	for _, fixture := range fixtures {
+		if kickoff.IsZero() || (!fixture.Kickoff.IsZero() && fixture.Kickoff.Before(kickoff)) {
+			kickoff = fixture.Kickoff
+		}
+	}
+	return kickoff, nil
+}
+
+// teamsWhere returns the clubs whose number of fixtures in the game week matches
+func (c *FixtureCalendar) teamsWhere(gw int, match func(fixtures int) bool) ([]int, error) {
+	teams := make([]int, 0)
+	for _, team := range c.teams {
+		fixtures, err := c.TeamFixtures(team, gw)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if match(fixtures) {
+			teams = append(teams, team)
+		}
+	}
+	return teams, nil
+}
+
+// PlayerGameweek is a player's data for a whole game week, combining every fixture they played in it
+type PlayerGameweek struct {
+	GW          int
+	Fixtures    []PlayerGWInfo
+	TotalPoints int
+	Minutes     int
+}
+
+// Blank reports whether the player had no fixture in the game week
+func (g PlayerGameweek) Blank() bool {
+	return len(g.Fixtures) == 0
+}
+
+// Double reports whether the player had more than one fixture in the game week
+func (g PlayerGameweek) Double() bool {
+	return len(g.Fixtures) > 1
+}
+
+// GroupByGameweek groups a player's game week data into each of the game weeks, which are given in order.
+// Game weeks where the player had no fixture are included as blanks, and data for any other game week is left out.
+func GroupByGameweek(gwData []PlayerGWInfo, gameweeks []int) []PlayerGameweek {
+	grouped := make([]PlayerGameweek, len(gameweeks))
+	index := make(map[int]int)
+	for key, gw := range gameweeks {
+		grouped[key].GW = gw
+		index[gw] = key
+	}
+	for _, gw := range gwData {
+		key, ok := index[gw.GW]
+		if !ok {
+			continue
+		}
+		playerGW := &grouped[key]
+		playerGW.Fixtures = append(playerGW.Fixtures, gw)
+		playerGW.TotalPoints += gw.TotalPoints
+		playerGW.Minutes += gw.Minutes
+	}
+	return grouped
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+// fixtureRows returns the game week data of a fixture, with a player from each club scoring the given points.
+// The player of each club has the club's ID times 100 as their ID.
+func fixtureRows(gw, fixture, home, away, points int) []PlayerGWInfo {
+	return []PlayerGWInfo{
+		{Element: PlayerID(home * 100), GW: gw, Fixture: fixture, OpponentTeam: away, WasHome: "True", TotalPoints: points, Minutes: 90},
+		{Element: PlayerID(away * 100), GW: gw, Fixture: fixture, OpponentTeam: home, WasHome: "False", TotalPoints: points, Minutes: 90},
+	}
+}
+
+// testCalendarData is a season of four clubs, where club 1 plays twice and club 2 blanks in game week 2, and
+// game week 3 is a gap in the numbering without any fixtures
+func testCalendarData() []PlayerGWInfo {
+	gwData := make([]PlayerGWInfo, 0)
+	for _, fixture := range [][]int{
+		{1, 1, 1, 2, 2},
+		{1, 2, 3, 4, 1},
+		{2, 3, 1, 3, 6},
+		{2, 4, 4, 1, 3},
+		{4, 5, 2, 4, 1},
+		{4, 6, 1, 3, 2},
+	} {
+		gwData = append(gwData, fixtureRows(fixture[0], fixture[1], fixture[2], fixture[3], fixture[4])...)
+	}
+	return gwData
+}
+
+func TestFixtureCalendar(t *testing.T) {
+	calendar := NewFixtureCalendar(testCalendarData())
+
+	if played := calendar.PlayedGameweeks(); !reflect.DeepEqual(played, []int{1, 2, 4}) {
+		t.Errorf("PlayedGameweeks() = %v, want [1 2 4]", played)
+	}
+
+	cases := []struct {
+		gw     int
+		double []int
+		blank  []int
+	}{
+		{1, []int{}, []int{}},
+		{2, []int{1}, []int{2}},
+		{3, []int{}, []int{}},
+		{4, []int{}, []int{}},
+	}
+	for _, c := range cases {
+		double, err := calendar.DoubleTeams(c.gw)
+		if err != nil {
+			t.Fatalf("DoubleTeams(%v) returned an error: %v", c.gw, err)
+		}
+		blank, err := calendar.BlankTeams(c.gw)
+		if err != nil {
+			t.Fatalf("BlankTeams(%v) returned an error: %v", c.gw, err)
+		}
+		if !reflect.DeepEqual(double, c.double) {
+			t.Errorf("DoubleTeams(%v) = %v, want %v", c.gw, double, c.double)
+		}
+		if !reflect.DeepEqual(blank, c.blank) {
+			t.Errorf("BlankTeams(%v) = %v, want %v", c.gw, blank, c.blank)
+		}
+	}
+
+	if _, err := calendar.BlankTeams(5); err == nil {
+		t.Errorf("BlankTeams(5) returned no error for a game week after the season")
+	}
+}
+
+func TestGroupByGameweek(t *testing.T) {
+	gwData := make([]PlayerGWInfo, 0)
+	for _, gw := range testCalendarData() {
+		if gw.Element == 100 {
+			gwData = append(gwData, gw)
+		}
+	}
+
+	grouped := GroupByGameweek(gwData, []int{1, 2, 4})
+	cases := []struct {
+		gw       int
+		fixtures int
+		points   int
+		minutes  int
+		double   bool
+	}{
+		{1, 1, 2, 90, false},
+		{2, 2, 9, 180, true},
+		{4, 1, 2, 90, false},
+	}
+	if len(grouped) != len(cases) {
+		t.Fatalf("GroupByGameweek returned %v game weeks, want %v", len(grouped), len(cases))
+	}
+	for key, c := range cases {
+		playerGW := grouped[key]
+		if playerGW.GW != c.gw || len(playerGW.Fixtures) != c.fixtures || playerGW.TotalPoints != c.points ||
+			playerGW.Minutes != c.minutes || playerGW.Double() != c.double || playerGW.Blank() {
+			t.Errorf("GroupByGameweek game week %v = %+v, want %v fixtures, %v points and %v minutes",
+				c.gw, playerGW, c.fixtures, c.points, c.minutes)
+		}
+	}
+
+	// A player without any data blanks in every game week
+	blanks := GroupByGameweek(nil, []int{2})
+	if !blanks[0].Blank() {
+		t.Errorf("GroupByGameweek without data = %+v, want a blank", blanks[0])
+	}
+}
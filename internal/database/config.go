@@ -0,0 +1,102 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/icelolly/go-errors"
+)
+
+// The supported database drivers, which share their names with the goqu dialects
+const DriverMySQL = "mysql"
+const DriverSQLite = "sqlite3"
+
+// Environment variables which can be used to configure the database connection
+const EnvDriver = "FPL_DB_DRIVER"
+const EnvDSN = "FPL_DB_DSN"
+
+// DefaultMaxOpenConns is the number of connections opened to the database when no limit is set, kept under the
+// MySQL default of 151 to prevent MySQL connection error 1040
+const DefaultMaxOpenConns = 50
+
+// Config is the database connection used by the Resolver.
+// For MySQL the DSN takes the form 'user:password@tcp(127.0.0.1:3306)/fpl', and for SQLite it is the file path.
+// MaxOpenConns limits the number of connections open at once, using DefaultMaxOpenConns when not set.
+type Config struct {
+	Driver       string `json:"driver"`
+	DSN          string `json:"dsn"`
+	MaxOpenConns int    `json:"max_open_conns"`
+}
+
+// Credentials is the data structure found in a 'credentials.json' file
+type Credentials struct {
+	DBUsername   string
+	DBPassword   string
+	DBAddress    string
+	DBSchemaName string
+}
+
+// Location returns where the database is, without any password, so it can be recorded alongside the results
+func (c Config) Location() string {
+	if c.Driver != DriverMySQL {
+		return c.DSN
+	}
+	mysqlConfig, err := mysql.ParseDSN(c.DSN)
+	if err != nil {
+		return ""
+	}
+	mysqlConfig.Passwd = ""
+	return mysqlConfig.FormatDSN()
+}
+
+// ConfigFromEnv returns the database connection set in the environment variables
+func ConfigFromEnv() Config {
+	return Config{
+		Driver: os.Getenv(EnvDriver),
+		DSN:    os.Getenv(EnvDSN),
+	}
+}
+
+// ReadConfig returns the database connection from a JSON config file
+func ReadConfig(filePath string) (Config, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return Config{}, errors.Wrap(err)
+	}
+	defer file.Close()
+
+	config := Config{}
+	if err := json.NewDecoder(file).Decode(&config); err != nil {
+		return Config{}, errors.Wrap(err)
+	}
+	return config, nil
+}
+
+// ReadCredentials returns a MySQL database connection from a 'credentials.json' file
+func ReadCredentials(filePath string) (Config, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return Config{}, errors.Wrap(err)
+	}
+	defer file.Close()
+
+	credentials := Credentials{}
+	if err := json.NewDecoder(file).Decode(&credentials); err != nil {
+		return Config{}, errors.Wrap(err)
+	}
+	if credentials.DBUsername == "" || credentials.DBAddress == "" || credentials.DBSchemaName == "" {
+		return Config{}, errors.New("Credentials file must include dbUsername, dbAddress and dbSchemaName")
+	}
+
+	return Config{
+		Driver: DriverMySQL,
+		DSN: fmt.Sprintf("%v:%v@tcp(%v)/%v",
+			credentials.DBUsername,
+			credentials.DBPassword,
+			credentials.DBAddress,
+			credentials.DBSchemaName,
+		),
+	}, nil
+}
@@ -10,36 +10,66 @@ var dataGW1 = goqu.T("GW1")
 // playerData is the database table used to store the player data by game week
 var playerData = goqu.T("GW_data")
 
-// Database login info
-const dbSchemaName = "fpl"
-const dbAddress = "127.0.0.1:3306"
-const dbUsername = "root"
-
-// Credentials is the data structure found in 'credentials.json'
-type Credentials struct {
-	DBUsername string
-	DBPassword string
-}
+// playerIdentities is the database table used to link the same player across seasons
+var playerIdentities = goqu.T("player_ids")
+
+// teamData is the database table used to store the Premier League clubs
+var teamData = goqu.T("teams")
 
 // PlayerInfo is the structure of data found in the 'GW1' table
 type PlayerInfo struct {
-	ID        int
+	ID        PlayerID
 	FirstName string
 	LastName  string
-	Position  string
+	Position  Position
 	Price     int
 	Team      int
+	Season    string
 }
 
 // PlayerGWInfo is the structure of data found in the 'GW_data' table
 type PlayerGWInfo struct {
 	Name         string
-	Element      int
+	Element      PlayerID
 	OpponentTeam int
 	TotalPoints  int
 	Value        int
 	WasHome      string
 	GW           int
+	Season       string
+	Minutes      int
+	GoalsScored  int
+	Assists      int
+	CleanSheets  int
+	Bonus        int
+	BPS          int
+	Saves        int
+	YellowCards  int
+	RedCards     int
+	ICTIndex     float64
+	Selected     int
+	TransfersIn  int
+	TransfersOut int
+	KickoffTime  string
+	Fixture      int
+}
+
+// PlayerIdentity is the structure of data found in the 'player_ids' table.
+// It links a player's element ID in one season to their code, which stays the same across seasons.
+type PlayerIdentity struct {
+	Code      PlayerCode
+	Season    string
+	Element   PlayerID
+	FirstName string
+	LastName  string
+}
+
+// TeamInfo is the structure of data found in the 'teams' table
+type TeamInfo struct {
+	ID        int
+	Name      string
+	ShortName string
+	Season    string
 }
 
 // Constant time format to be used throughout project
@@ -0,0 +1,478 @@
+package database
+
+import (
+	"encoding/csv"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/icelolly/go-errors"
+)
+
+/*	INGEST:
+	This file of code reads seasons of data from a local checkout of the vaastav
+	Fantasy-Premier-League repository, and loads them into the FPL database tables.
+	The data directory looks like 'Fantasy-Premier-League/data', and holds a directory for each season.
+*/
+
+// How many rows to write in a single insert statement
+const insertBatchSize int = 500
+
+// seasonPattern matches the names of the season directories, e.g. '2019-20'
+var seasonPattern = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}$`)
+
+// Dataset is a single season of FPL data, as read from the vaastav CSV files
+type Dataset struct {
+	Season     string
+	Players    []PlayerInfo
+	GWData     []PlayerGWInfo
+	Identities []PlayerIdentity
+	Teams      []TeamInfo
+}
+
+// ListSeasons returns the season directories found in the data directory, from oldest to newest
+func ListSeasons(dataDir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dataDir)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	seasons := make([]string, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() || !seasonPattern.MatchString(entry.Name()) {
+			continue
+		}
+		// Skip any seasons which don't have player data yet
+		if _, err := os.Stat(filepath.Join(dataDir, entry.Name(), "players_raw.csv")); err != nil {
+			continue
+		}
+		seasons = append(seasons, entry.Name())
+	}
+	sort.Strings(seasons)
+
+	if len(seasons) == 0 {
+		return nil, errors.New("No seasons found in directory: " + dataDir)
+	}
+	return seasons, nil
+}
+
+// ReadDataset reads the 'players_raw.csv', 'gws/merged_gw.csv' and 'teams.csv' files of the season
+func ReadDataset(dataDir, season string) (Dataset, error) {
+	seasonDir := filepath.Join(dataDir, season)
+
+	// The players and their identities are both taken from 'players_raw.csv', which is only read once
+	playersTable, err := readCSV(filepath.Join(seasonDir, "players_raw.csv"))
+	if err != nil {
+		return Dataset{}, errors.Wrap(err)
+	}
+	players, err := readPlayers(playersTable)
+	if err != nil {
+		return Dataset{}, errors.Wrap(err)
+	}
+	identities, err := readIdentities(playersTable)
+	if err != nil {
+		return Dataset{}, errors.Wrap(err)
+	}
+
+	gwData, err := ReadGWData(filepath.Join(seasonDir, "gws", "merged_gw.csv"))
+	if err != nil {
+		return Dataset{}, errors.Wrap(err)
+	}
+
+	// Older seasons of the dataset don't include a 'teams.csv' file
+	teams := make([]TeamInfo, 0)
+	teamsPath := filepath.Join(seasonDir, "teams.csv")
+	if _, err := os.Stat(teamsPath); err == nil {
+		if teams, err = ReadTeams(teamsPath); err != nil {
+			return Dataset{}, errors.Wrap(err)
+		}
+	}
+
+	// Label each row of data with the season it belongs to
+	for key := range players {
+		players[key].Season = season
+	}
+	for key := range gwData {
+		gwData[key].Season = season
+	}
+	for key := range identities {
+		identities[key].Season = season
+	}
+	for key := range teams {
+		teams[key].Season = season
+	}
+
+	return Dataset{Season: season, Players: players, GWData: gwData, Identities: identities, Teams: teams}, nil
+}
+
+// readPlayers reads the pre-season player data from the table of 'players_raw.csv'
+func readPlayers(table *csvTable) ([]PlayerInfo, error) {
+	players := make([]PlayerInfo, 0, len(table.rows))
+	for _, row := range table.rows {
+		var player PlayerInfo
+		id, err := table.getInt(row, "id")
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		player.ID = PlayerID(id)
+		if player.FirstName, err = table.get(row, "first_name"); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if player.LastName, err = table.get(row, "second_name"); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if player.Team, err = table.getInt(row, "team"); err != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		elementType, err := table.get(row, "element_type")
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if player.Position, err = ParsePosition(elementType); err != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		// The pre-season price is the current price, minus any price changes since the start of the season
+		nowCost, err := table.getInt(row, "now_cost")
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		costChange, err := table.getInt(row, "cost_change_start")
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		player.Price = nowCost - costChange
+
+		players = append(players, player)
+	}
+	return players, nil
+}
+
+// ReadGWData reads the player data for each game week from 'gws/merged_gw.csv'
+func ReadGWData(filePath string) ([]PlayerGWInfo, error) {
+	table, err := readCSV(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	// Older seasons of the dataset record the game week in the 'round' column
+	gwColumn := "GW"
+	if _, ok := table.columns[gwColumn]; !ok {
+		gwColumn = "round"
+	}
+
+	gwData := make([]PlayerGWInfo, 0, len(table.rows))
+	for _, row := range table.rows {
+		var gw PlayerGWInfo
+		if gw.Name, err = table.get(row, "name"); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		element, err := table.getInt(row, "element")
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		gw.Element = PlayerID(element)
+		if gw.OpponentTeam, err = table.getInt(row, "opponent_team"); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if gw.TotalPoints, err = table.getInt(row, "total_points"); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if gw.Value, err = table.getInt(row, "value"); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if gw.WasHome, err = table.get(row, "was_home"); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if gw.GW, err = table.getInt(row, gwColumn); err != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		// Read the match stats, which are all whole numbers apart from the ICT index
+		stats := map[string]*int{
+			"minutes":       &gw.Minutes,
+			"goals_scored":  &gw.GoalsScored,
+			"assists":       &gw.Assists,
+			"clean_sheets":  &gw.CleanSheets,
+			"bonus":         &gw.Bonus,
+			"bps":           &gw.BPS,
+			"saves":         &gw.Saves,
+			"yellow_cards":  &gw.YellowCards,
+			"red_cards":     &gw.RedCards,
+			"selected":      &gw.Selected,
+			"transfers_in":  &gw.TransfersIn,
+			"transfers_out": &gw.TransfersOut,
+		}
+		for column, stat := range stats {
+			if *stat, err = table.getInt(row, column); err != nil {
+				return nil, errors.Wrap(err)
+			}
+		}
+		if gw.ICTIndex, err = table.getFloat(row, "ict_index"); err != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		if gw.Fixture, err = table.getInt(row, "fixture"); err != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		// The kickoff time is left empty in datasets which don't record it
+		if _, ok := table.columns["kickoff_time"]; ok {
+			if gw.KickoffTime, err = table.get(row, "kickoff_time"); err != nil {
+				return nil, errors.Wrap(err)
+			}
+		}
+
+		gwData = append(gwData, gw)
+	}
+	return gwData, nil
+}
+
+// readIdentities reads the 'code' of each player from the table of 'players_raw.csv', which is the same in every
+// season, unlike the element 'id'
+func readIdentities(table *csvTable) ([]PlayerIdentity, error) {
+	identities := make([]PlayerIdentity, 0, len(table.rows))
+	for _, row := range table.rows {
+		var identity PlayerIdentity
+		code, err := table.getInt(row, "code")
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		identity.Code = PlayerCode(code)
+
+		element, err := table.getInt(row, "id")
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		identity.Element = PlayerID(element)
+		if identity.FirstName, err = table.get(row, "first_name"); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if identity.LastName, err = table.get(row, "second_name"); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		identities = append(identities, identity)
+	}
+	return identities, nil
+}
+
+// ReadTeams reads the Premier League clubs from 'teams.csv'
+func ReadTeams(filePath string) ([]TeamInfo, error) {
+	table, err := readCSV(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	teams := make([]TeamInfo, 0, len(table.rows))
+	for _, row := range table.rows {
+		var team TeamInfo
+		if team.ID, err = table.getInt(row, "id"); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if team.Name, err = table.get(row, "name"); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		if team.ShortName, err = table.get(row, "short_name"); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		teams = append(teams, team)
+	}
+	return teams, nil
+}
+
+// Ingest populates the FPL tables with the dataset. The tables should first be emptied using 'CreateTables'.
+func (r *Resolver) Ingest(dataset Dataset) error {
+	players := make([][]interface{}, 0, len(dataset.Players))
+	for _, player := range dataset.Players {
+		players = append(players, []interface{}{
+			player.ID,
+			player.FirstName,
+			player.LastName,
+			player.Position,
+			player.Team,
+			player.Price,
+			player.Season,
+		})
+	}
+	if err := r.insertRows(dataGW1, players,
+		"id", "first_name", "second_name", "position", "team", "price", "season",
+	); err != nil {
+		return errors.Wrap(err)
+	}
+
+	gwData := make([][]interface{}, 0, len(dataset.GWData))
+	for _, gw := range dataset.GWData {
+		gwData = append(gwData, []interface{}{
+			gw.Name,
+			gw.Element,
+			gw.OpponentTeam,
+			gw.TotalPoints,
+			gw.Value,
+			gw.WasHome,
+			gw.GW,
+			gw.Season,
+			gw.Minutes,
+			gw.GoalsScored,
+			gw.Assists,
+			gw.CleanSheets,
+			gw.Bonus,
+			gw.BPS,
+			gw.Saves,
+			gw.YellowCards,
+			gw.RedCards,
+			gw.ICTIndex,
+			gw.Selected,
+			gw.TransfersIn,
+			gw.TransfersOut,
+			gw.KickoffTime,
+			gw.Fixture,
+		})
+	}
+	if err := r.insertRows(playerData, gwData,
+		"name", "element", "opponent_team", "total_points", "value", "was_home", "GW", "season",
+		"minutes", "goals_scored", "assists", "clean_sheets", "bonus", "bps", "saves",
+		"yellow_cards", "red_cards", "ict_index", "selected", "transfers_in", "transfers_out",
+		"kickoff_time", "fixture",
+	); err != nil {
+		return errors.Wrap(err)
+	}
+
+	identities := make([][]interface{}, 0, len(dataset.Identities))
+	for _, identity := range dataset.Identities {
+		identities = append(identities, []interface{}{
+			identity.Code,
+			identity.Season,
+			identity.Element,
+			identity.FirstName,
+			identity.LastName,
+		})
+	}
+	if err := r.insertRows(playerIdentities, identities,
+		"code", "season", "element", "first_name", "second_name",
+	); err != nil {
+		return errors.Wrap(err)
+	}
+
+	teams := make([][]interface{}, 0, len(dataset.Teams))
+	for _, team := range dataset.Teams {
+		teams = append(teams, []interface{}{team.ID, team.Name, team.ShortName, team.Season})
+	}
+	if err := r.insertRows(teamData, teams, "id", "name", "short_name", "season"); err != nil {
+		return errors.Wrap(err)
+	}
+
+	return nil
+}
+
+// insertRows writes the rows into the table in batches, to keep each statement a manageable size
+func (r *Resolver) insertRows(table exp.IdentifierExpression, rows [][]interface{}, columns ...interface{}) error {
+	for start := 0; start < len(rows); start += insertBatchSize {
+		end := start + insertBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		query, args, err := r.sqlBuilder.Insert(table).Cols(columns...).Vals(rows[start:end]...).ToSQL()
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		if _, err := r.FPLDB.Exec(query, args...); err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// csvTable is the contents of a CSV file, with the header row used to look up each column
+type csvTable struct {
+	columns map[string]int
+	rows    [][]string
+}
+
+// readCSV reads the whole CSV file into memory
+func readCSV(filePath string) (*csvTable, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	table := &csvTable{columns: make(map[string]int), rows: make([][]string, 0)}
+	for key, column := range header {
+		// Some of the files are saved with a byte order mark at the start
+		table.columns[strings.TrimPrefix(strings.TrimSpace(column), "\ufeff")] = key
+	}
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		table.rows = append(table.rows, row)
+	}
+	return table, nil
+}
+
+// get returns the value of the column in the row
+func (t *csvTable) get(row []string, column string) (string, error) {
+	key, ok := t.columns[column]
+	if !ok {
+		return "", errors.New("Missing column: " + column)
+	}
+	return toUTF8(strings.TrimSpace(row[key])), nil
+}
+
+// getInt returns the value of the column in the row, as an integer
+func (t *csvTable) getInt(row []string, column string) (int, error) {
+	value, err := t.get(row, column)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	return number, nil
+}
+
+// getFloat returns the value of the column in the row, as a decimal
+func (t *csvTable) getFloat(row []string, column string) (float64, error) {
+	value, err := t.get(row, column)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	return number, nil
+}
+
+// toUTF8 converts the text from latin-1, which is used by the older seasons of the dataset
+func toUTF8(text string) string {
+	if utf8.ValidString(text) {
+		return text
+	}
+	runes := make([]rune, 0, len(text))
+	for i := 0; i < len(text); i++ {
+		runes = append(runes, rune(text[i]))
+	}
+	return string(runes)
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/icelolly/go-errors"
+)
+
+// MemoryRepository is an in-memory PlayerRepository, which doesn't require a database connection.
+// The player selections are made against the same PlayerPool used by the database Resolver.
+type MemoryRepository struct {
+	pools      map[string]*PlayerPool
+	gwData     map[string][]PlayerGWInfo
+	identities []PlayerIdentity
+}
+
+// NewMemoryRepository creates a MemoryRepository from the pre-season player data, the game week data
+// and the player identities
+func NewMemoryRepository(players []PlayerInfo, gwData []PlayerGWInfo, identities []PlayerIdentity) *MemoryRepository {
+	repo := &MemoryRepository{
+		pools:      make(map[string]*PlayerPool),
+		gwData:     make(map[string][]PlayerGWInfo),
+		identities: identities,
+	}
+
+	seasonPlayers := make(map[string][]PlayerInfo)
+	for _, player := range players {
+		seasonPlayers[player.Season] = append(seasonPlayers[player.Season], player)
+	}
+	for season, players := range seasonPlayers {
+		repo.pools[season] = NewPlayerPool(players)
+	}
+
+	for _, gw := range gwData {
+		repo.gwData[gw.Season] = append(repo.gwData[gw.Season], gw)
+	}
+	return repo
+}
+
+// LoadMemoryRepository creates a MemoryRepository from the seasons found in the vaastav data directory
+func LoadMemoryRepository(dataDir string, seasons []string) (*MemoryRepository, error) {
+	players := make([]PlayerInfo, 0)
+	gwData := make([]PlayerGWInfo, 0)
+	identities := make([]PlayerIdentity, 0)
+	for _, season := range seasons {
+		dataset, err := ReadDataset(dataDir, season)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		players = append(players, dataset.Players...)
+		gwData = append(gwData, dataset.GWData...)
+		identities = append(identities, dataset.Identities...)
+	}
+	return NewMemoryRepository(players, gwData, identities), nil
+}
+
+// Seasons returns each season of data held in memory, from oldest to newest
+func (m *MemoryRepository) Seasons() ([]string, error) {
+	seasons := make([]string, 0, len(m.pools))
+	for season := range m.pools {
+		seasons = append(seasons, season)
+	}
+	sort.Strings(seasons)
+
+	if len(seasons) == 0 {
+		return nil, errors.New("Empty db response")
+	}
+	return seasons, nil
+}
+
+// GetPositionPlayers returns every player in the position from the player pool
+func (m *MemoryRepository) GetPositionPlayers(season string, position Position) ([]PlayerInfo, error) {
+	pool, err := m.pool(season)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return pool.Position(position), nil
+}
+
+// GetRandomPlayer searches the player pool for a random, cheap player
+func (m *MemoryRepository) GetRandomPlayer(rng *rand.Rand, season string, position Position, maxPrice int) (PlayerInfo, error) {
+	pool, err := m.pool(season)
+	if err != nil {
+		return PlayerInfo{}, errors.Wrap(err)
+	}
+	return pool.GetRandomPlayer(rng, position, maxPrice)
+}
+
+// UpgradePlayer takes the player passed in, and finds a more expensive alternative
+func (m *MemoryRepository) UpgradePlayer(rng *rand.Rand, player PlayerInfo) (PlayerInfo, error) {
+	pool, err := m.pool(player.Season)
+	if err != nil {
+		return PlayerInfo{}, errors.Wrap(err)
+	}
+	return pool.UpgradePlayer(rng, player)
+}
+
+// DowngradePlayer takes the player passed in, and finds a less expensive alternative
+func (m *MemoryRepository) DowngradePlayer(player PlayerInfo) (PlayerInfo, error) {
+	pool, err := m.pool(player.Season)
+	if err != nil {
+		return PlayerInfo{}, errors.Wrap(err)
+	}
+	return pool.DowngradePlayer(player)
+}
+
+// ReplacePlayer takes the player info and returns an equally priced alternative
+func (m *MemoryRepository) ReplacePlayer(player PlayerInfo, exitingTeam []PlayerInfo, excludedClubs []int) (PlayerInfo, error) {
+	pool, err := m.pool(player.Season)
+	if err != nil {
+		return PlayerInfo{}, errors.Wrap(err)
+	}
+	return pool.ReplacePlayer(player, exitingTeam, excludedClubs)
+}
+
+// GetPlayerData takes the player ID and returns the data for each match played in the season
+func (m *MemoryRepository) GetPlayerData(season string, playerID PlayerID) ([]PlayerGWInfo, error) {
+	playerData := make([]PlayerGWInfo, 0)
+	for _, gw := range m.gwData[season] {
+		if gw.Element == playerID {
+			playerData = append(playerData, gw)
+		}
+	}
+
+	if len(playerData) == 0 {
+		return nil, errors.New("Empty db response")
+	}
+	return playerData, nil
+}
+
+// GetAllPlayerData returns the data for each match played in the season, by every player
+func (m *MemoryRepository) GetAllPlayerData(season string) ([]PlayerGWInfo, error) {
+	if len(m.gwData[season]) == 0 {
+		return nil, errors.New("Empty db response")
+	}
+	return m.gwData[season], nil
+}
+
+// GetPlayerIdentities takes the player code and returns their element ID in each season, from oldest to newest
+func (m *MemoryRepository) GetPlayerIdentities(code PlayerCode) ([]PlayerIdentity, error) {
+	identities := make([]PlayerIdentity, 0)
+	for _, identity := range m.identities {
+		if identity.Code == code {
+			identities = append(identities, identity)
+		}
+	}
+
+	if len(identities) == 0 {
+		return nil, errors.New("Empty db response")
+	}
+
+	sort.SliceStable(identities, func(i, j int) bool {
+		return identities[i].Season < identities[j].Season
+	})
+	return identities, nil
+}
+
+// GetPlayerIdentity takes the player's element ID in the season, and returns their identity across seasons
+func (m *MemoryRepository) GetPlayerIdentity(season string, playerID PlayerID) (PlayerIdentity, error) {
+	for _, identity := range m.identities {
+		if identity.Season == season && identity.Element == playerID {
+			return identity, nil
+		}
+	}
+	return PlayerIdentity{}, errors.New("Empty db response")
+}
+
+// GetPlayerHistory takes the player code and returns the data for each match played in every season,
+// ordered by season and game week
+func (m *MemoryRepository) GetPlayerHistory(code PlayerCode) ([]PlayerGWInfo, error) {
+	identities, err := m.GetPlayerIdentities(code)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	history := make([]PlayerGWInfo, 0)
+	for _, identity := range identities {
+		playerData, err := m.GetPlayerData(identity.Season, identity.Element)
+		if err != nil {
+			// The player may not have been picked in every season they were registered
+			continue
+		}
+		sort.SliceStable(playerData, func(i, j int) bool {
+			return playerData[i].GW < playerData[j].GW
+		})
+		history = append(history, playerData...)
+	}
+
+	if len(history) == 0 {
+		return nil, errors.New("Empty db response")
+	}
+	return history, nil
+}
+
+// pool returns the player pool of the season
+func (m *MemoryRepository) pool(season string) (*PlayerPool, error) {
+	pool, ok := m.pools[season]
+	if !ok {
+		return nil, errors.New("No player data for season: " + season)
+	}
+	return pool, nil
+}
@@ -0,0 +1,234 @@
+package database
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// testRepository holds the midfielders of two seasons, with the same player registered in both under different
+// element IDs
+func testRepository() *MemoryRepository {
+	players := []PlayerInfo{
+		{ID: 4, Position: Midfielder, Price: 70, Team: 2, Season: "2019-20"},
+		{ID: 1, Position: Midfielder, Price: 45, Team: 1, Season: "2019-20"},
+		{ID: 3, Position: Midfielder, Price: 55, Team: 1, Season: "2019-20"},
+		{ID: 2, Position: Midfielder, Price: 55, Team: 2, Season: "2019-20"},
+		{ID: 5, Position: Forward, Price: 60, Team: 3, Season: "2019-20"},
+		{ID: 9, Position: Midfielder, Price: 75, Team: 2, Season: "2018-19"},
+	}
+	gwData := []PlayerGWInfo{
+		{Element: 4, GW: 2, TotalPoints: 8, Season: "2019-20"},
+		{Element: 4, GW: 1, TotalPoints: 3, Season: "2019-20"},
+		{Element: 1, GW: 1, TotalPoints: 1, Season: "2019-20"},
+		{Element: 9, GW: 1, TotalPoints: 6, Season: "2018-19"},
+	}
+	identities := []PlayerIdentity{
+		{Code: 100, Season: "2019-20", Element: 4},
+		{Code: 100, Season: "2018-19", Element: 9},
+		{Code: 200, Season: "2019-20", Element: 1},
+	}
+	return NewMemoryRepository(players, gwData, identities)
+}
+
+// playerIDs returns the ID of each player, in order
+func playerIDs(players []PlayerInfo) []PlayerID {
+	ids := make([]PlayerID, len(players))
+	for key, player := range players {
+		ids[key] = player.ID
+	}
+	return ids
+}
+
+func TestMemoryRepositorySeasons(t *testing.T) {
+	seasons, err := testRepository().Seasons()
+	if err != nil {
+		t.Fatalf("Seasons() returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(seasons, []string{"2018-19", "2019-20"}) {
+		t.Errorf("Seasons() = %v, want [2018-19 2019-20]", seasons)
+	}
+
+	if _, err := NewMemoryRepository(nil, nil, nil).Seasons(); err == nil {
+		t.Errorf("Seasons() of an empty repository returned no error")
+	}
+}
+
+func TestMemoryRepositoryGetPositionPlayers(t *testing.T) {
+	cases := []struct {
+		season   string
+		position Position
+		want     []PlayerID
+	}{
+		// Players of the same price are ordered by ID, whatever order they were loaded in
+		{"2019-20", Midfielder, []PlayerID{1, 2, 3, 4}},
+		{"2019-20", Forward, []PlayerID{5}},
+		{"2019-20", Goalkeeper, []PlayerID{}},
+		{"2018-19", Midfielder, []PlayerID{9}},
+	}
+	repo := testRepository()
+	for _, c := range cases {
+		players, err := repo.GetPositionPlayers(c.season, c.position)
+		if err != nil {
+			t.Fatalf("GetPositionPlayers(%v, %v) returned an error: %v", c.season, c.position, err)
+		}
+		if ids := playerIDs(players); !reflect.DeepEqual(ids, c.want) {
+			t.Errorf("GetPositionPlayers(%v, %v) = %v, want %v", c.season, c.position, ids, c.want)
+		}
+	}
+
+	if _, err := repo.GetPositionPlayers("2020-21", Midfielder); err == nil {
+		t.Errorf("GetPositionPlayers of a missing season returned no error")
+	}
+}
+
+func TestMemoryRepositoryPlayerSelections(t *testing.T) {
+	repo := testRepository()
+	midfielder := func(id PlayerID) PlayerInfo {
+		players, _ := repo.GetPositionPlayers("2019-20", Midfielder)
+		for _, player := range players {
+			if player.ID == id {
+				return player
+			}
+		}
+		t.Fatalf("No midfielder with ID %v", id)
+		return PlayerInfo{}
+	}
+
+	cases := []struct {
+		name   string
+		pick   func(rng *rand.Rand) (PlayerInfo, error)
+		want   []PlayerID
+		errors bool
+	}{
+		{"random at most 55", func(rng *rand.Rand) (PlayerInfo, error) {
+			return repo.GetRandomPlayer(rng, "2019-20", Midfielder, 55)
+		}, []PlayerID{1, 2, 3}, false},
+		{"random below the cheapest", func(rng *rand.Rand) (PlayerInfo, error) {
+			return repo.GetRandomPlayer(rng, "2019-20", Midfielder, 40)
+		}, nil, true},
+		{"upgrade", func(rng *rand.Rand) (PlayerInfo, error) {
+			return repo.UpgradePlayer(rng, midfielder(1))
+		}, []PlayerID{2, 3, 4}, false},
+		{"upgrade the most expensive", func(rng *rand.Rand) (PlayerInfo, error) {
+			return repo.UpgradePlayer(rng, midfielder(4))
+		}, []PlayerID{4}, false},
+		{"downgrade", func(rng *rand.Rand) (PlayerInfo, error) {
+			return repo.DowngradePlayer(midfielder(4))
+		}, []PlayerID{3}, false},
+		{"downgrade the cheapest", func(rng *rand.Rand) (PlayerInfo, error) {
+			return repo.DowngradePlayer(midfielder(1))
+		}, []PlayerID{1}, false},
+		{"replace", func(rng *rand.Rand) (PlayerInfo, error) {
+			return repo.ReplacePlayer(midfielder(3), []PlayerInfo{midfielder(3)}, nil)
+		}, []PlayerID{2}, false},
+		{"replace from another club", func(rng *rand.Rand) (PlayerInfo, error) {
+			return repo.ReplacePlayer(midfielder(3), []PlayerInfo{midfielder(3)}, []int{2})
+		}, []PlayerID{1}, false},
+		{"replace without any alternative", func(rng *rand.Rand) (PlayerInfo, error) {
+			return repo.ReplacePlayer(midfielder(3), []PlayerInfo{midfielder(1), midfielder(3)}, []int{2})
+		}, nil, true},
+	}
+	for _, c := range cases {
+		for seed := int64(0); seed < 20; seed++ {
+			player, err := c.pick(rand.New(rand.NewSource(seed)))
+			if c.errors {
+				if err == nil {
+					t.Errorf("%v returned %v, want an error", c.name, player.ID)
+				}
+				break
+			}
+			if err != nil {
+				t.Fatalf("%v returned an error: %v", c.name, err)
+			}
+			found := false
+			for _, id := range c.want {
+				found = found || player.ID == id
+			}
+			if !found {
+				t.Errorf("%v returned %v, want one of %v", c.name, player.ID, c.want)
+			}
+		}
+	}
+}
+
+func TestMemoryRepositoryGetPlayerData(t *testing.T) {
+	repo := testRepository()
+	cases := []struct {
+		season string
+		player PlayerID
+		points []int
+		errors bool
+	}{
+		{"2019-20", 4, []int{8, 3}, false},
+		{"2019-20", 1, []int{1}, false},
+		{"2019-20", 3, nil, true},
+		{"2018-19", 4, nil, true},
+	}
+	for _, c := range cases {
+		gwData, err := repo.GetPlayerData(c.season, c.player)
+		if c.errors {
+			if err == nil {
+				t.Errorf("GetPlayerData(%v, %v) returned no error", c.season, c.player)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("GetPlayerData(%v, %v) returned an error: %v", c.season, c.player, err)
+		}
+		points := make([]int, len(gwData))
+		for key, gw := range gwData {
+			points[key] = gw.TotalPoints
+		}
+		if !reflect.DeepEqual(points, c.points) {
+			t.Errorf("GetPlayerData(%v, %v) points = %v, want %v", c.season, c.player, points, c.points)
+		}
+	}
+
+	if gwData, err := repo.GetAllPlayerData("2019-20"); err != nil || len(gwData) != 3 {
+		t.Errorf("GetAllPlayerData(2019-20) = %v rows, %v, want 3 rows", len(gwData), err)
+	}
+	if _, err := repo.GetAllPlayerData("2020-21"); err == nil {
+		t.Errorf("GetAllPlayerData of a missing season returned no error")
+	}
+}
+
+func TestMemoryRepositoryIdentities(t *testing.T) {
+	repo := testRepository()
+
+	identities, err := repo.GetPlayerIdentities(100)
+	if err != nil {
+		t.Fatalf("GetPlayerIdentities(100) returned an error: %v", err)
+	}
+	seasons := make([]string, len(identities))
+	for key, identity := range identities {
+		seasons[key] = identity.Season
+	}
+	if !reflect.DeepEqual(seasons, []string{"2018-19", "2019-20"}) {
+		t.Errorf("GetPlayerIdentities(100) seasons = %v, want [2018-19 2019-20]", seasons)
+	}
+
+	identity, err := repo.GetPlayerIdentity("2018-19", 9)
+	if err != nil || identity.Code != 100 {
+		t.Errorf("GetPlayerIdentity(2018-19, 9) = %+v, %v, want code 100", identity, err)
+	}
+	if _, err := repo.GetPlayerIdentity("2018-19", 4); err == nil {
+		t.Errorf("GetPlayerIdentity of an element ID from another season returned no error")
+	}
+
+	// The history runs through each season in order, and each season by game week
+	history, err := repo.GetPlayerHistory(100)
+	if err != nil {
+		t.Fatalf("GetPlayerHistory(100) returned an error: %v", err)
+	}
+	points := make([]int, len(history))
+	for key, gw := range history {
+		points[key] = gw.TotalPoints
+	}
+	if !reflect.DeepEqual(points, []int{6, 3, 8}) {
+		t.Errorf("GetPlayerHistory(100) points = %v, want [6 3 8]", points)
+	}
+	if _, err := repo.GetPlayerHistory(300); err == nil {
+		t.Errorf("GetPlayerHistory of an unknown player returned no error")
+	}
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/icelolly/go-errors"
+)
+
+// PointsMatrix holds the points scored, minutes played, fixtures and price of every player in every game week.
+// Each player has a row of the matrix, indexed by game week, so scoring a team needs no database queries.
+type PointsMatrix struct {
+	players   map[PlayerID]int
+	points    [][]int
+	minutes   [][]int
+	home      [][]bool
+	fixtures  [][]int
+	values    [][]int
+	gameweeks int
+}
+
+// NewPointsMatrix builds the matrix from the game week data of every player.
+// A player with two fixtures in a game week has the points and minutes from both added together.
+// A player with no fixture in a game week keeps their price from the game week before.
+func NewPointsMatrix(gwData []PlayerGWInfo) *PointsMatrix {
+	m := &PointsMatrix{players: make(map[PlayerID]int)}
+
+	// Some seasons have gaps in the game week numbers, so size the matrix by the last game week
+	for _, gw := range gwData {
+		if gw.GW > m.gameweeks {
+			m.gameweeks = gw.GW
+		}
+	}
+
+	for _, gw := range gwData {
+		row, ok := m.players[gw.Element]
+		if !ok {
+			row = len(m.points)
+			m.players[gw.Element] = row
+			m.points = append(m.points, make([]int, m.gameweeks+1))
+			m.minutes = append(m.minutes, make([]int, m.gameweeks+1))
+			m.home = append(m.home, make([]bool, m.gameweeks+1))
+			m.fixtures = append(m.fixtures, make([]int, m.gameweeks+1))
+			m.values = append(m.values, make([]int, m.gameweeks+1))
+		}
+		m.points[row][gw.GW] += gw.TotalPoints
+		m.minutes[row][gw.GW] += gw.Minutes
+		m.home[row][gw.GW] = m.home[row][gw.GW] || wasHome(gw.WasHome)
+		m.fixtures[row][gw.GW]++
+		m.values[row][gw.GW] = gw.Value
+	}
+
+	// Fill in the price of each player in the game weeks they had no fixture
+	for _, values := range m.values {
+		for gw := 2; gw <= m.gameweeks; gw++ {
+			if values[gw] == 0 {
+				values[gw] = values[gw-1]
+			}
+		}
+		for gw := m.gameweeks - 1; gw >= 1; gw-- {
+			if values[gw] == 0 {
+				values[gw] = values[gw+1]
+			}
+		}
+	}
+	return m
+}
+
+// Gameweeks returns the number of the last game week in the season
+func (m *PointsMatrix) Gameweeks() int {
+	return m.gameweeks
+}
+
+// Points returns the points scored by the player in the game week
+func (m *PointsMatrix) Points(playerID PlayerID, gw int) (int, error) {
+	row, err := m.cell(playerID, gw)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	return m.points[row][gw], nil
+}
+
+// Minutes returns the minutes played by the player in the game week
+func (m *PointsMatrix) Minutes(playerID PlayerID, gw int) (int, error) {
+	row, err := m.cell(playerID, gw)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	return m.minutes[row][gw], nil
+}
+
+// Home returns whether the player had a home fixture in the game week
+func (m *PointsMatrix) Home(playerID PlayerID, gw int) (bool, error) {
+	row, err := m.cell(playerID, gw)
+	if err != nil {
+		return false, errors.Wrap(err)
+	}
+	return m.home[row][gw], nil
+}
+
+// Fixtures returns the number of fixtures the player had in the game week, which is zero in a blank game week
+// and two in a double game week
+func (m *PointsMatrix) Fixtures(playerID PlayerID, gw int) (int, error) {
+	row, err := m.cell(playerID, gw)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	return m.fixtures[row][gw], nil
+}
+
+// Value returns the price of the player in the game week
+func (m *PointsMatrix) Value(playerID PlayerID, gw int) (int, error) {
+	row, err := m.cell(playerID, gw)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	return m.values[row][gw], nil
+}
+
+// Total returns the points scored by the player between the two game weeks, inclusive
+func (m *PointsMatrix) Total(playerID PlayerID, fromGW, toGW int) (int, error) {
+	row, err := m.row(playerID)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	if fromGW < 1 {
+		fromGW = 1
+	}
+	if toGW > m.gameweeks {
+		toGW = m.gameweeks
+	}
+
+	pointsTotal := 0
+	for gw := fromGW; gw <= toGW; gw++ {
+		pointsTotal += m.points[row][gw]
+	}
+	return pointsTotal, nil
+}
+
+// SeasonTotal returns the points scored by the player across the whole season
+func (m *PointsMatrix) SeasonTotal(playerID PlayerID) (int, error) {
+	return m.Total(playerID, 1, m.gameweeks)
+}
+
+// row returns the player's row of the matrix
+func (m *PointsMatrix) row(playerID PlayerID) (int, error) {
+	row, ok := m.players[playerID]
+	if !ok {
+		return 0, errors.New("No game week data for player: " + strconv.Itoa(int(playerID)))
+	}
+	return row, nil
+}
+
+// cell returns the player's row of the matrix, after checking the game week is within the season
+func (m *PointsMatrix) cell(playerID PlayerID, gw int) (int, error) {
+	row, err := m.row(playerID)
+	if err != nil {
+		return 0, errors.Wrap(err)
+	}
+	if gw < 1 || gw > m.gameweeks {
+		return 0, errors.New("Game week out of range: " + strconv.Itoa(gw))
+	}
+	return row, nil
+}
+
+// wasHome parses the 'was_home' column, which the datasets store as either 'True' or '1'
+func wasHome(value string) bool {
+	return strings.EqualFold(value, "true") || value == "1"
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/icelolly/go-errors"
+)
+
+// PlayerPool is an in-memory index of the pre-season players, grouped by position and sorted by price, which
+// the random teams are picked from. Picking a player never changes the pool.
+type PlayerPool struct {
+	positions map[Position][]PlayerInfo
+}
+
+// NewPlayerPool indexes the players by position, with each position sorted from cheapest to most expensive.
+// Players of the same price are sorted by ID, so the order depends only on the players and not on the order
+// they were read in, and the same random numbers pick the same players from every data source.
+func NewPlayerPool(players []PlayerInfo) *PlayerPool {
+	pool := &PlayerPool{positions: make(map[Position][]PlayerInfo)}
+	for _, player := range players {
+		pool.positions[player.Position] = append(pool.positions[player.Position], player)
+	}
+	for _, positionPlayers := range pool.positions {
+		sort.Slice(positionPlayers, func(i, j int) bool {
+			if positionPlayers[i].Price != positionPlayers[j].Price {
+				return positionPlayers[i].Price < positionPlayers[j].Price
+			}
+			return positionPlayers[i].ID < positionPlayers[j].ID
+		})
+	}
+	return pool
+}
+
+// Position returns every player in the position, from cheapest to most expensive.
+// The players are shared with the pool, so must not be modified.
+func (p *PlayerPool) Position(position Position) []PlayerInfo {
+	return p.positions[position]
+}
+
+// GetRandomPlayer returns a random player in the position, costing no more than the maximum price, picked using
+// the random number generator
+func (p *PlayerPool) GetRandomPlayer(rng *rand.Rand, position Position, maxPrice int) (PlayerInfo, error) {
+	players := p.positions[position]
+	suitablePlayers := players[:p.countAtMost(players, maxPrice)]
+
+	if len(suitablePlayers) == 0 {
+		return PlayerInfo{}, errors.New("No players available in position: " + string(position))
+	}
+
+	// Return a random player from the list
+	return suitablePlayers[rng.Intn(len(suitablePlayers))], nil
+}
+
+// UpgradePlayer takes the player passed in, and finds a random, more expensive alternative, picked using the
+// random number generator
+func (p *PlayerPool) UpgradePlayer(rng *rand.Rand, player PlayerInfo) (PlayerInfo, error) {
+	players := p.positions[player.Position]
+	suitablePlayers := players[p.countAtMost(players, player.Price):]
+
+	// If no upgrade is available, return back the player in question
+	if len(suitablePlayers) == 0 {
+		return player, nil
+	}
+
+	// Return a random player from the list
+	return suitablePlayers[rng.Intn(len(suitablePlayers))], nil
+}
+
+// DowngradePlayer takes the player passed in, and finds the most expensive, cheaper alternative
+func (p *PlayerPool) DowngradePlayer(player PlayerInfo) (PlayerInfo, error) {
+	players := p.positions[player.Position]
+	suitablePlayers := players[:p.countAtMost(players, player.Price-1)]
+
+	// If no downgrade is available, return back the player in question
+	if len(suitablePlayers) == 0 {
+		return player, nil
+	}
+
+	return suitablePlayers[len(suitablePlayers)-1], nil
+}
+
+// ReplacePlayer takes the player info and returns the most expensive alternative, no more expensive than
+// the player, who isn't already in the team and doesn't play for one of the excluded clubs
+func (p *PlayerPool) ReplacePlayer(player PlayerInfo, exitingTeam []PlayerInfo, excludedClubs []int) (PlayerInfo, error) {
+	players := p.positions[player.Position]
+
+	// Work down from the most expensive of the suitably priced players
+	for i := p.countAtMost(players, player.Price) - 1; i >= 0; i-- {
+		if players[i].ID == player.ID {
+			continue
+		}
+
+		excludedClub := false
+		for _, club := range excludedClubs {
+			if players[i].Team == club {
+				excludedClub = true
+			}
+		}
+		if excludedClub {
+			continue
+		}
+
+		duplicatePlayer := false
+		for _, existingPlayer := range exitingTeam {
+			if players[i].ID == existingPlayer.ID {
+				duplicatePlayer = true
+			}
+		}
+		if !duplicatePlayer {
+			return players[i], nil
+		}
+	}
+
+	return PlayerInfo{}, errors.New("No replacement available for player")
+}
+
+// countAtMost returns how many of the price-sorted players cost no more than the price
+func (p *PlayerPool) countAtMost(players []PlayerInfo, price int) int {
+	return sort.Search(len(players), func(i int) bool {
+		return players[i].Price > price
+	})
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"strings"
+
+	"github.com/icelolly/go-errors"
+)
+
+// Position is the playing position of a player, stored as a single letter in the 'GW1' table
+type Position string
+
+// The four playing positions
+const (
+	Goalkeeper Position = "G"
+	Defender   Position = "D"
+	Midfielder Position = "M"
+	Forward    Position = "F"
+)
+
+// Positions lists every playing position, in the order they are listed in a squad
+var Positions = []Position{Goalkeeper, Defender, Midfielder, Forward}
+
+// PlayerID is a player's FPL element ID, which is only unique within a single season
+type PlayerID int
+
+// PlayerCode is a player's FPL code, which stays the same across seasons
+type PlayerCode int
+
+// ParsePosition reads the position from the vaastav names (GK, DEF, MID, FWD), the FPL element
+// types (1, 2, 3, 4) or the single letters used in the 'GW1' table (G, D, M, F)
+func ParsePosition(text string) (Position, error) {
+	switch strings.ToUpper(strings.TrimSpace(text)) {
+	case "GK", "GKP", "1", "G":
+		return Goalkeeper, nil
+	case "DEF", "2", "D":
+		return Defender, nil
+	case "MID", "3", "M":
+		return Midfielder, nil
+	case "FWD", "4", "F":
+		return Forward, nil
+	}
+	return "", errors.New("Unknown position: " + text)
+}
+
+// Name returns the vaastav name of the position
+func (p Position) Name() string {
+	switch p {
+	case Goalkeeper:
+		return "GK"
+	case Defender:
+		return "DEF"
+	case Midfielder:
+		return "MID"
+	case Forward:
+		return "FWD"
+	}
+	return string(p)
+}
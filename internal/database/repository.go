@@ -0,0 +1,49 @@
+package database
+
+import "math/rand"
+
+// PlayerRepository is the set of player lookups needed to simulate FPL teams.
+// It is implemented by the database Resolver, and by the in-memory MemoryRepository.
+// The upgrade, downgrade and replace lookups search the same season as the player passed in.
+type PlayerRepository interface {
+	// Seasons returns each season of data available, from oldest to newest
+	Seasons() ([]string, error)
+
+	// GetPositionPlayers returns every player in the position, from cheapest to most expensive pre-season
+	GetPositionPlayers(season string, position Position) ([]PlayerInfo, error)
+
+	// GetRandomPlayer returns a random player in the position, costing no more than the maximum price, picked
+	// using the random number generator
+	GetRandomPlayer(rng *rand.Rand, season string, position Position, maxPrice int) (PlayerInfo, error)
+
+	// UpgradePlayer returns a random, more expensive alternative to the player, picked using the random
+	// number generator
+	UpgradePlayer(rng *rand.Rand, player PlayerInfo) (PlayerInfo, error)
+
+	// DowngradePlayer returns the most expensive, cheaper alternative to the player
+	DowngradePlayer(player PlayerInfo) (PlayerInfo, error)
+
+	// ReplacePlayer returns an equally priced alternative to the player, who isn't already in the team
+	// and doesn't play for one of the excluded clubs
+	ReplacePlayer(player PlayerInfo, exitingTeam []PlayerInfo, excludedClubs []int) (PlayerInfo, error)
+
+	// GetPlayerData returns the data for each match played by the player in the season
+	GetPlayerData(season string, playerID PlayerID) ([]PlayerGWInfo, error)
+
+	// GetAllPlayerData returns the data for each match played in the season, by every player
+	GetAllPlayerData(season string) ([]PlayerGWInfo, error)
+
+	// GetPlayerIdentities returns the player's element ID in each season, from oldest to newest
+	GetPlayerIdentities(code PlayerCode) ([]PlayerIdentity, error)
+
+	// GetPlayerIdentity returns the identity across seasons of the player's element ID in the season
+	GetPlayerIdentity(season string, playerID PlayerID) (PlayerIdentity, error)
+
+	// GetPlayerHistory returns the data for each match played by the player in every season,
+	// ordered by season and game week
+	GetPlayerHistory(code PlayerCode) ([]PlayerGWInfo, error)
+}
+
+// Ensure both implementations satisfy the interface
+var _ PlayerRepository = (*Resolver)(nil)
+var _ PlayerRepository = (*MemoryRepository)(nil)
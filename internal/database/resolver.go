@@ -2,71 +2,74 @@ package database
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
-	"os"
+	"sync"
 
 	"github.com/doug-martin/goqu/v9"
 	"github.com/icelolly/go-errors"
 
 	// Needed to construct SQL queries
 	_ "github.com/doug-martin/goqu/v9/dialect/mysql"
+	_ "github.com/doug-martin/goqu/v9/dialect/sqlite3"
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 // Resolver is the entry-point for accessing the football data
 type Resolver struct {
-	FPLDB      *sql.DB
-	sqlBuilder *goqu.Database
+	FPLDB       *sql.DB
+	sqlBuilder  *goqu.Database
+	config      Config
+	playerPools map[string]*PlayerPool
+	poolMutex   sync.Mutex
 }
 
-// NewResolver creates and returns an empty Resolver
-func NewResolver() *Resolver {
-	return &Resolver{}
+// NewResolver creates and returns an empty Resolver, which will connect to the configured database
+func NewResolver(config Config) *Resolver {
+	if config.Driver == "" {
+		config.Driver = DriverMySQL
+	}
+	if config.MaxOpenConns < 1 {
+		config.MaxOpenConns = DefaultMaxOpenConns
+	}
+	return &Resolver{config: config}
 }
 
 // ResolveFPLDB returns or initiates a new database connection
-func (r *Resolver) ResolveFPLDB() *sql.DB {
+func (r *Resolver) ResolveFPLDB() (*sql.DB, error) {
 	if r.FPLDB == nil {
-		fmt.Printf("Initiating a new database connection to : %v\n", dbSchemaName)
-
-		dbPassword, err := ReadCredentials()
-		if err != nil {
-			fmt.Printf("Error while reading db login credentials: %v\n", err)
-			return nil
+		if r.config.Driver != DriverMySQL && r.config.Driver != DriverSQLite {
+			return nil, errors.New("Unsupported database driver: " + r.config.Driver)
 		}
+		if r.config.DSN == "" {
+			return nil, errors.New("No database connection has been configured")
+		}
+
+		fmt.Printf("Initiating a new %v database connection\n", r.config.Driver)
 
-		databaseLogin := fmt.Sprintf("%v:%v@tcp(%v)/%v", dbUsername, dbPassword, dbAddress, dbSchemaName)
-		conn, err := sql.Open("mysql", databaseLogin)
+		conn, err := sql.Open(r.config.Driver, r.config.DSN)
 		if err != nil {
-			fmt.Printf("Error while resolving database: %v\n", err)
-			return nil
+			return nil, errors.Wrap(err)
+		}
+		if err := conn.Ping(); err != nil {
+			_ = conn.Close()
+			return nil, errors.Wrap(err)
 		}
+		conn.SetMaxOpenConns(r.config.MaxOpenConns)
 		r.FPLDB = conn
 	}
-	return r.FPLDB
+	return r.FPLDB, nil
 }
 
-// ResolveMySQLQueryBuilder creates a new goqu-based query builder, using the mysql dialect.
-func (r *Resolver) ResolveMySQLQueryBuilder() *goqu.Database {
-	if r.sqlBuilder == nil {
-		r.sqlBuilder = goqu.New("mysql", nil)
-	}
-	return r.sqlBuilder
+// MaxOpenConns returns the number of connections which can be open to the database at once
+func (r *Resolver) MaxOpenConns() int {
+	return r.config.MaxOpenConns
 }
 
-// ReadCredentials returns the database login info from the 'credentials.json' file
-func ReadCredentials() (string, error) {
-	file, err := os.Open("internal/database/credentials.json")
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-	decoder := json.NewDecoder(file)
-	configuration := Credentials{}
-	err = decoder.Decode(&configuration)
-	if err != nil {
-		return "", errors.Wrap(err)
+// ResolveQueryBuilder creates a new goqu-based query builder, using the dialect of the database driver.
+func (r *Resolver) ResolveQueryBuilder() *goqu.Database {
+	if r.sqlBuilder == nil {
+		r.sqlBuilder = goqu.New(r.config.Driver, nil)
 	}
-	return configuration.DBPassword, nil
+	return r.sqlBuilder
 }
@@ -2,78 +2,61 @@ package database
 
 import (
 	"math/rand"
-	"sort"
 
 	"github.com/doug-martin/goqu/v9"
 	"github.com/icelolly/go-errors"
 )
 
-// GetRandomPlayer searches the database for a random, cheap player
-func (r *Resolver) GetRandomPlayer(position string) (PlayerInfo, error) {
-	query, args, err := r.sqlBuilder.From(dataGW1).Where(
-		goqu.And(
-			goqu.C("position").Eq(position),
-			goqu.C("price").Lte(50),
-		),
+// Seasons returns each season stored in the database, from oldest to newest
+func (r *Resolver) Seasons() ([]string, error) {
+	query, args, err := r.sqlBuilder.From(dataGW1).Select("season").Distinct().Order(
+		goqu.C("season").Asc(),
 	).ToSQL()
-
 	if err != nil {
-		return PlayerInfo{}, errors.Wrap(err)
+		return nil, errors.Wrap(err)
 	}
 
 	rows, err := r.FPLDB.Query(query, args...)
 	if err != nil {
-		return PlayerInfo{}, errors.Wrap(err)
+		return nil, errors.Wrap(err)
 	}
 
-	suitablePlayers := make([]PlayerInfo, 0)
+	seasons := make([]string, 0)
 	for rows.Next() {
-		var player PlayerInfo
-		if err := rows.Scan(
-			&player.ID,
-			&player.FirstName,
-			&player.LastName,
-			&player.Position,
-			&player.Team,
-			&player.Price,
-		); err != nil {
+		var season string
+		if err := rows.Scan(&season); err != nil {
 			_ = rows.Close()
-			return PlayerInfo{}, errors.Wrap(err)
+			return nil, errors.Wrap(err)
 		}
-		suitablePlayers = append(suitablePlayers, player)
+		seasons = append(seasons, season)
 	}
 
 	if err := rows.Close(); err != nil {
-		return PlayerInfo{}, errors.Wrap(err)
+		return nil, errors.Wrap(err)
 	}
 
-	if len(suitablePlayers) == 0 {
-		return PlayerInfo{}, errors.New("Empty db response")
+	if len(seasons) == 0 {
+		return nil, errors.New("Empty db response")
 	}
 
-	// Return a random player from the list
-	return suitablePlayers[rand.Intn(len(suitablePlayers))], nil
+	return seasons, nil
 }
 
-// UpgradePlayer takes the player passed in, and finds a more expensive alternative
-func (r *Resolver) UpgradePlayer(player PlayerInfo) (PlayerInfo, error) {
+// GetPlayers returns every player in the pre-season player data of the season
+func (r *Resolver) GetPlayers(season string) ([]PlayerInfo, error) {
 	query, args, err := r.sqlBuilder.From(dataGW1).Where(
-		goqu.And(
-			goqu.C("position").Eq(player.Position),
-			goqu.C("price").Gt(player.Price),
-		),
+		goqu.C("season").Eq(season),
 	).ToSQL()
-
 	if err != nil {
-		return PlayerInfo{}, errors.Wrap(err)
+		return nil, errors.Wrap(err)
 	}
 
 	rows, err := r.FPLDB.Query(query, args...)
 	if err != nil {
-		return PlayerInfo{}, errors.Wrap(err)
+		return nil, errors.Wrap(err)
 	}
 
-	suitablePlayers := make([]PlayerInfo, 0)
+	players := make([]PlayerInfo, 0)
 	for rows.Next() {
 		var player PlayerInfo
 		if err := rows.Scan(
@@ -83,146 +66,200 @@ func (r *Resolver) UpgradePlayer(player PlayerInfo) (PlayerInfo, error) {
 			&player.Position,
 			&player.Team,
 			&player.Price,
+			&player.Season,
 		); err != nil {
 			_ = rows.Close()
-			return PlayerInfo{}, errors.Wrap(err)
+			return nil, errors.Wrap(err)
 		}
-		suitablePlayers = append(suitablePlayers, player)
+		players = append(players, player)
 	}
 
 	if err := rows.Close(); err != nil {
-		return PlayerInfo{}, errors.Wrap(err)
+		return nil, errors.Wrap(err)
 	}
 
-	// If no upgrade is available, return back the player in question
-	if len(suitablePlayers) == 0 {
-		return player, nil
+	if len(players) == 0 {
+		return nil, errors.New("Empty db response")
 	}
 
-	// Return a random player from the list
-	return suitablePlayers[rand.Intn(len(suitablePlayers))], nil
+	return players, nil
 }
 
-// DowngradePlayer takes the player passed in, and finds a less expensive alternative
-func (r *Resolver) DowngradePlayer(player PlayerInfo) (PlayerInfo, error) {
-	query, args, err := r.sqlBuilder.From(dataGW1).Where(
-		goqu.And(
-			goqu.C("position").Eq(player.Position),
-			goqu.C("price").Lt(player.Price),
-		),
-	).ToSQL()
+// ResolvePlayerPool loads the pre-season player data of the season into memory the first time it is called,
+// so that the random team selections don't need to query the database
+func (r *Resolver) ResolvePlayerPool(season string) (*PlayerPool, error) {
+	r.poolMutex.Lock()
+	defer r.poolMutex.Unlock()
+
+	if r.playerPools == nil {
+		r.playerPools = make(map[string]*PlayerPool)
+	}
+	if _, ok := r.playerPools[season]; !ok {
+		players, err := r.GetPlayers(season)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		r.playerPools[season] = NewPlayerPool(players)
+	}
+	return r.playerPools[season], nil
+}
 
+// GetPositionPlayers returns every player in the position from the player pool
+func (r *Resolver) GetPositionPlayers(season string, position Position) ([]PlayerInfo, error) {
+	pool, err := r.ResolvePlayerPool(season)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return pool.Position(position), nil
+}
+
+// GetRandomPlayer searches the player pool for a random, cheap player
+func (r *Resolver) GetRandomPlayer(rng *rand.Rand, season string, position Position, maxPrice int) (PlayerInfo, error) {
+	pool, err := r.ResolvePlayerPool(season)
 	if err != nil {
 		return PlayerInfo{}, errors.Wrap(err)
 	}
+	return pool.GetRandomPlayer(rng, position, maxPrice)
+}
 
-	rows, err := r.FPLDB.Query(query, args...)
+// UpgradePlayer takes the player passed in, and finds a more expensive alternative
+func (r *Resolver) UpgradePlayer(rng *rand.Rand, player PlayerInfo) (PlayerInfo, error) {
+	pool, err := r.ResolvePlayerPool(player.Season)
 	if err != nil {
 		return PlayerInfo{}, errors.Wrap(err)
 	}
+	return pool.UpgradePlayer(rng, player)
+}
 
-	suitablePlayers := make([]PlayerInfo, 0)
-	for rows.Next() {
-		var player PlayerInfo
-		if err := rows.Scan(
-			&player.ID,
-			&player.FirstName,
-			&player.LastName,
-			&player.Position,
-			&player.Team,
-			&player.Price,
-		); err != nil {
-			_ = rows.Close()
-			return PlayerInfo{}, errors.Wrap(err)
-		}
-		suitablePlayers = append(suitablePlayers, player)
+// DowngradePlayer takes the player passed in, and finds a less expensive alternative
+func (r *Resolver) DowngradePlayer(player PlayerInfo) (PlayerInfo, error) {
+	pool, err := r.ResolvePlayerPool(player.Season)
+	if err != nil {
+		return PlayerInfo{}, errors.Wrap(err)
 	}
+	return pool.DowngradePlayer(player)
+}
 
-	if err := rows.Close(); err != nil {
+// ReplacePlayer takes the player info and returns an equally priced alternative
+func (r *Resolver) ReplacePlayer(player PlayerInfo, exitingTeam []PlayerInfo, excludedClubs []int) (PlayerInfo, error) {
+	pool, err := r.ResolvePlayerPool(player.Season)
+	if err != nil {
 		return PlayerInfo{}, errors.Wrap(err)
 	}
+	return pool.ReplacePlayer(player, exitingTeam, excludedClubs)
+}
 
-	// Sort the players according to their price
-	sort.SliceStable(suitablePlayers, func(i, j int) bool {
-		return suitablePlayers[i].Price < suitablePlayers[j].Price
-	})
+// GetPlayerData takes the player ID and returns the data for each match played in the season
+func (r *Resolver) GetPlayerData(season string, playerID PlayerID) ([]PlayerGWInfo, error) {
+	return r.queryPlayerData(r.sqlBuilder.From(playerData).Where(
+		goqu.C("season").Eq(season),
+		goqu.C("element").Eq(playerID),
+	))
+}
 
-	// If no upgrade is available, return back the player in question
-	if len(suitablePlayers) == 0 {
-		return player, nil
-	}
+// GetAllPlayerData returns the data for each match played in the season, by every player
+func (r *Resolver) GetAllPlayerData(season string) ([]PlayerGWInfo, error) {
+	return r.queryPlayerData(r.sqlBuilder.From(playerData).Where(
+		goqu.C("season").Eq(season),
+	))
+}
 
-	// Return a random player from the list
-	return suitablePlayers[len(suitablePlayers)-1], nil
+// GetPlayerIdentities takes the player code and returns their element ID in each season, from oldest to newest
+func (r *Resolver) GetPlayerIdentities(code PlayerCode) ([]PlayerIdentity, error) {
+	return r.queryPlayerIdentities(r.sqlBuilder.From(playerIdentities).Where(
+		goqu.C("code").Eq(code),
+	).Order(goqu.C("season").Asc()))
 }
 
-// ReplacePlayer takes the player info and returns an equally priced alternative
-func (r *Resolver) ReplacePlayer(player PlayerInfo, exitingTeam []PlayerInfo) (PlayerInfo, error) {
-	query, args, err := r.sqlBuilder.From(dataGW1).Where(
-		goqu.And(
-			goqu.C("id").Neq(player.ID),
-			goqu.C("position").Eq(player.Position),
-			goqu.C("price").Lte(player.Price),
+// GetPlayerIdentity takes the player's element ID in the season, and returns their identity across seasons
+func (r *Resolver) GetPlayerIdentity(season string, playerID PlayerID) (PlayerIdentity, error) {
+	identities, err := r.queryPlayerIdentities(r.sqlBuilder.From(playerIdentities).Where(
+		goqu.C("season").Eq(season),
+		goqu.C("element").Eq(playerID),
+	))
+	if err != nil {
+		return PlayerIdentity{}, errors.Wrap(err)
+	}
+	return identities[0], nil
+}
+
+// GetPlayerHistory takes the player code and returns the data for each match played in every season,
+// ordered by season and game week
+func (r *Resolver) GetPlayerHistory(code PlayerCode) ([]PlayerGWInfo, error) {
+	return r.queryPlayerData(r.sqlBuilder.From(playerData).Select(playerData.All()).Join(
+		playerIdentities,
+		goqu.On(
+			playerData.Col("season").Eq(playerIdentities.Col("season")),
+			playerData.Col("element").Eq(playerIdentities.Col("element")),
 		),
-	).ToSQL()
+	).Where(
+		playerIdentities.Col("code").Eq(code),
+	).Order(
+		playerData.Col("season").Asc(),
+		playerData.Col("GW").Asc(),
+	))
+}
 
+// queryPlayerData runs the query against the game week data table, and returns the matching rows
+func (r *Resolver) queryPlayerData(dataset *goqu.SelectDataset) ([]PlayerGWInfo, error) {
+	query, args, err := dataset.ToSQL()
 	if err != nil {
-		return PlayerInfo{}, errors.Wrap(err)
+		return nil, errors.Wrap(err)
 	}
 
 	rows, err := r.FPLDB.Query(query, args...)
 	if err != nil {
-		return PlayerInfo{}, errors.Wrap(err)
+		return nil, errors.Wrap(err)
 	}
 
-	suitablePlayers := make([]PlayerInfo, 0)
+	playerData := make([]PlayerGWInfo, 0)
 	for rows.Next() {
-		var player PlayerInfo
+		var gw PlayerGWInfo
 		if err := rows.Scan(
-			&player.ID,
-			&player.FirstName,
-			&player.LastName,
-			&player.Position,
-			&player.Team,
-			&player.Price,
+			&gw.Name,
+			&gw.Element,
+			&gw.OpponentTeam,
+			&gw.TotalPoints,
+			&gw.Value,
+			&gw.WasHome,
+			&gw.GW,
+			&gw.Season,
+			&gw.Minutes,
+			&gw.GoalsScored,
+			&gw.Assists,
+			&gw.CleanSheets,
+			&gw.Bonus,
+			&gw.BPS,
+			&gw.Saves,
+			&gw.YellowCards,
+			&gw.RedCards,
+			&gw.ICTIndex,
+			&gw.Selected,
+			&gw.TransfersIn,
+			&gw.TransfersOut,
+			&gw.KickoffTime,
+			&gw.Fixture,
 		); err != nil {
 			_ = rows.Close()
-			return PlayerInfo{}, errors.Wrap(err)
-		}
-
-		duplicatePlayer := false
-		for _, existingPlayer := range exitingTeam {
-			if player.ID == existingPlayer.ID {
-				duplicatePlayer = true
-			}
-		}
-		if !duplicatePlayer {
-			suitablePlayers = append(suitablePlayers, player)
+			return nil, errors.Wrap(err)
 		}
+		playerData = append(playerData, gw)
 	}
 
-	sort.SliceStable(suitablePlayers, func(i, j int) bool {
-		return suitablePlayers[i].Price < suitablePlayers[j].Price
-	})
-
 	if err := rows.Close(); err != nil {
-		return PlayerInfo{}, errors.Wrap(err)
+		return nil, errors.Wrap(err)
 	}
 
-	if len(suitablePlayers) == 0 {
-		return PlayerInfo{}, errors.New("Empty db response")
+	if len(playerData) == 0 {
+		return nil, errors.New("Empty db response")
 	}
 
-	// Return a random player from the list
-	return suitablePlayers[len(suitablePlayers)-1], nil
+	return playerData, nil
 }
 
-// GetPlayerData takes the player ID and returns the data for each match played
-func (r *Resolver) GetPlayerData(playerID int) ([]PlayerGWInfo, error) {
-	query, args, err := r.sqlBuilder.From(playerData).Where(
-		goqu.C("element").Eq(playerID),
-	).ToSQL()
-
+// queryPlayerIdentities runs the query against the player identity table, and returns the matching rows
+func (r *Resolver) queryPlayerIdentities(dataset *goqu.SelectDataset) ([]PlayerIdentity, error) {
+	query, args, err := dataset.ToSQL()
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
@@ -232,32 +269,29 @@ func (r *Resolver) GetPlayerData(playerID int) ([]PlayerGWInfo, error) {
 		return nil, errors.Wrap(err)
 	}
 
-	playerData := make([]PlayerGWInfo, 0)
+	identities := make([]PlayerIdentity, 0)
 	for rows.Next() {
-		var gw PlayerGWInfo
+		var identity PlayerIdentity
 		if err := rows.Scan(
-			&gw.Name,
-			&gw.Element,
-			&gw.OpponentTeam,
-			&gw.TotalPoints,
-			&gw.Value,
-			&gw.WasHome,
-			&gw.GW,
+			&identity.Code,
+			&identity.Season,
+			&identity.Element,
+			&identity.FirstName,
+			&identity.LastName,
 		); err != nil {
 			_ = rows.Close()
 			return nil, errors.Wrap(err)
 		}
-		playerData = append(playerData, gw)
+		identities = append(identities, identity)
 	}
 
 	if err := rows.Close(); err != nil {
 		return nil, errors.Wrap(err)
 	}
 
-	if len(playerData) == 0 {
+	if len(identities) == 0 {
 		return nil, errors.New("Empty db response")
 	}
 
-	// Return a random player from the list
-	return playerData, nil
+	return identities, nil
 }
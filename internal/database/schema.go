@@ -0,0 +1,95 @@
+package database
+
+import (
+	"github.com/icelolly/go-errors"
+)
+
+// The column order of each table must match the order used by the 'rows.Scan' calls in 'retriever.go',
+// since the retriever selects every column from the table.
+
+// createGW1 creates the table used to store the pre-season player data
+const createGW1 = `CREATE TABLE GW1 (
+	id INT NOT NULL,
+	first_name VARCHAR(64) NOT NULL,
+	second_name VARCHAR(64) NOT NULL,
+	position VARCHAR(1) NOT NULL,
+	team INT NOT NULL,
+	price INT NOT NULL,
+	season VARCHAR(7) NOT NULL,
+	PRIMARY KEY (season, id)
+)`
+
+// createGWData creates the table used to store the player data by game week
+const createGWData = `CREATE TABLE GW_data (
+	name VARCHAR(128) NOT NULL,
+	element INT NOT NULL,
+	opponent_team INT NOT NULL,
+	total_points INT NOT NULL,
+	value INT NOT NULL,
+	was_home VARCHAR(5) NOT NULL,
+	GW INT NOT NULL,
+	season VARCHAR(7) NOT NULL,
+	minutes INT NOT NULL,
+	goals_scored INT NOT NULL,
+	assists INT NOT NULL,
+	clean_sheets INT NOT NULL,
+	bonus INT NOT NULL,
+	bps INT NOT NULL,
+	saves INT NOT NULL,
+	yellow_cards INT NOT NULL,
+	red_cards INT NOT NULL,
+	ict_index DECIMAL(5,1) NOT NULL,
+	selected INT NOT NULL,
+	transfers_in INT NOT NULL,
+	transfers_out INT NOT NULL,
+	kickoff_time VARCHAR(20) NOT NULL,
+	fixture INT NOT NULL
+)`
+
+// createGWDataIndex speeds up the per-player lookups made by 'GetPlayerData'
+const createGWDataIndex = `CREATE INDEX GW_data_element ON GW_data (season, element)`
+
+// createPlayerIDs creates the table used to link the same player across seasons
+const createPlayerIDs = `CREATE TABLE player_ids (
+	code INT NOT NULL,
+	season VARCHAR(7) NOT NULL,
+	element INT NOT NULL,
+	first_name VARCHAR(64) NOT NULL,
+	second_name VARCHAR(64) NOT NULL,
+	PRIMARY KEY (season, element)
+)`
+
+// createPlayerIDsIndex speeds up the lookups of a player's seasons made by 'GetPlayerIdentities'
+const createPlayerIDsIndex = `CREATE INDEX player_ids_code ON player_ids (code)`
+
+// createTeams creates the table used to store the Premier League clubs
+const createTeams = `CREATE TABLE teams (
+	id INT NOT NULL,
+	name VARCHAR(64) NOT NULL,
+	short_name VARCHAR(3) NOT NULL,
+	season VARCHAR(7) NOT NULL,
+	PRIMARY KEY (season, id)
+)`
+
+// CreateTables drops any existing FPL tables, and re-creates them empty
+func (r *Resolver) CreateTables() error {
+	statements := []string{
+		"DROP TABLE IF EXISTS GW1",
+		"DROP TABLE IF EXISTS GW_data",
+		"DROP TABLE IF EXISTS player_ids",
+		"DROP TABLE IF EXISTS teams",
+		createGW1,
+		createGWData,
+		createGWDataIndex,
+		createPlayerIDs,
+		createPlayerIDsIndex,
+		createTeams,
+	}
+
+	for _, statement := range statements {
+		if _, err := r.FPLDB.Exec(statement); err != nil {
+			return errors.Wrap(err)
+		}
+	}
+	return nil
+}
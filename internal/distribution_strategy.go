@@ -5,8 +5,8 @@ import (
 	"fpl-strategy-tester/internal/database"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/icelolly/go-errors"
 )
@@ -17,187 +17,244 @@ import (
 	a ideal cost distribution of cheap to expensive players exists.
 */
 
-// RunCostVariationStrategy takes the simulated random teams over a range of total values in order to determine
+// Results files for each strategy, along with their column headers
+const CostVariationFile = "cost_variation.csv"
+const CostVariationHeader = "Season, Team Price, Average Points\n"
+const CostDistributionFile = "cost_distribution.csv"
+const CostDistributionHeader = "Season, " +
+	"Team Category, " +
+	"5th Percentile ," +
+	"25th Percentile, " +
+	"50th Percentile, " +
+	"75th Percentile, " +
+	"95th Percentile\n"
+
+func init() {
+	RegisterStrategy(costVariationName, func(r *Resolver) Strategy { return &CostVariationStrategy{resolver: r} })
+	RegisterStrategy(costDistributionName, func(r *Resolver) Strategy { return &CostDistributionStrategy{resolver: r} })
+}
+
+// distributionBudgetMargin is how far under budget a team can be, and still be included in the cost distribution
+const distributionBudgetMargin = 50
+
+// Names used to pick each strategy from the command line
+const costVariationName = "cost-variation"
+const costDistributionName = "cost-distribution"
+
+// CostVariationStrategy takes the simulated random teams over a range of total values in order to determine
 // the relationship between cost and points
-func (r *Resolver) RunCostVariationStrategy(simulatedTeams chan []database.PlayerInfo) error {
+type CostVariationStrategy struct {
+	resolver *Resolver
+}
 
-	// Data map to store [teamPrice][]teamPoints
-	var m sync.Map
-
-	// Simulate distribution strategy in batches (Prevent MySQL connection error 1040)
-	for j := 0; j < (MaxQueries / maxBatchSize); j++ {
-
-		// Manage concurrency
-		wg := &sync.WaitGroup{}
-		wg.Add(maxBatchSize)
-
-		for i := 0; i < maxBatchSize; i++ {
-			go func() {
-				defer wg.Done()
-
-				// Read the next team from the channel
-				team := <-simulatedTeams
-
-				// Calculate the overall team points
-				teamPoints, err := r.CalculateTeamPoints(team)
-				if err != nil {
-					fmt.Println(err)
-					return
-				}
-
-				// Calculate the overall team price
-				teamPrice := CalculatePrice(team)
-
-				// Add points value to correct map position, using sync map for concurrency
-				currentPoints, ok := m.Load(teamPrice)
-				if !ok {
-					m.Store(teamPrice, []int{teamPoints})
-				} else {
-					currentPoints = append(currentPoints.([]int), teamPoints)
-					m.Store(teamPrice, currentPoints)
-				}
-
-				// Add the data back onto the end of the channel so it can be processed by other strategies
-				simulatedTeams <- team
-			}()
-		}
-		wg.Wait()
+// costPoints is the price and points of a single team
+type costPoints struct {
+	price  int
+	points int
+}
+
+// Name returns the name used to pick the strategy from the command line
+func (s *CostVariationStrategy) Name() string {
+	return costVariationName
+}
+
+// Description returns a summary of what the strategy tests
+func (s *CostVariationStrategy) Description() string {
+	return "Average points of the teams at each team price"
+}
+
+// Parameters returns the captaincy policy used to score the teams
+func (s *CostVariationStrategy) Parameters() map[string]string {
+	return map[string]string{"captaincy": s.resolver.MostExpensiveCaptain().Name}
+}
+
+// Tables returns the results file of the strategy
+func (s *CostVariationStrategy) Tables() []ResultsTable {
+	return []ResultsTable{{File: CostVariationFile, Header: CostVariationHeader, Comparable: true}}
+}
+
+// Evaluate calculates the overall points and price of the team
+func (s *CostVariationStrategy) Evaluate(team Squad) (interface{}, error) {
+	teamPoints, err := s.resolver.CalculateTeamPoints(team, s.resolver.MostExpensiveCaptain())
+	if err != nil {
+		return nil, errors.Wrap(err)
 	}
+	return costPoints{price: team.Price(), points: teamPoints}, nil
+}
+
+// Aggregate returns a results row for each team value, labelled with the season
+func (s *CostVariationStrategy) Aggregate(season string, results []interface{}) ([][]string, error) {
+
+	rules := s.resolver.ResolveRules(season)
+
+	// Data map to store [teamPrice][]teamPoints
+	m, _ := groupByPrice(results)
 
 	// For each possible map store, calculate the average
 	consolidatedData := make([]string, 0)
-	for i := 750; i <= 1000; i += 10 {
+	for i := rules.MinTeamValue; i <= rules.Budget; i += 10 {
 
 		// If the map position is empty, submit a zero value.
 		// If the map position is not empty, calculate an average points value based on the contents of the map.
-		if pointsTotal, ok := m.Load(i); !ok {
-			consolidatedData = append(consolidatedData, fmt.Sprintf("%v, %v", i, 0))
+		if pointsTotal, ok := m[i]; !ok {
+			consolidatedData = append(consolidatedData, fmt.Sprintf("%v, %v, %v", season, i, 0))
 		} else {
 			pointsSum := 0
-			for _, points := range pointsTotal.([]int) {
+			for _, points := range pointsTotal {
 				pointsSum += points
 			}
-			averagePoints := float64(pointsSum) / float64(len(pointsTotal.([]int)))
-			consolidatedData = append(consolidatedData, fmt.Sprintf("%v, %.2f", i, averagePoints))
+			averagePoints := float64(pointsSum) / float64(len(pointsTotal))
+			consolidatedData = append(consolidatedData, fmt.Sprintf("%v, %v, %.2f", season, i, averagePoints))
 		}
 	}
 
-	// Empty the results file of any old data, and write the new data to the file
-	resultsFilePath := "internal/simulation_results/cost_variation.csv"
-	if err := truncateFile(resultsFilePath); err != nil {
-		return errors.Wrap(err)
-	}
-	if err := writeToFile(resultsFilePath, fmt.Sprintf("Team Price, Average Points\n")); err != nil {
-		return errors.Wrap(err)
+	return [][]string{consolidatedData}, nil
+}
+
+// Samples returns the points of the teams at each team value
+func (s *CostVariationStrategy) Samples(season string, results []interface{}) []Sample {
+	rules := s.resolver.ResolveRules(season)
+	m, teams := groupByPrice(results)
+
+	samples := make([]Sample, 0)
+	for i := rules.MinTeamValue; i <= rules.Budget; i += 10 {
+		if points, ok := m[i]; ok {
+			samples = append(samples, Sample{File: CostVariationFile, Season: season, Group: strconv.Itoa(i), Teams: teams[i], Values: points})
+		}
 	}
-	if err := writeToFile(resultsFilePath, strings.Join(consolidatedData, "\n")); err != nil {
-		return errors.Wrap(err)
+	return samples
+}
+
+// groupByPrice returns the points of the teams, by team price, along with the index of the team behind each value
+func groupByPrice(results []interface{}) (map[int][]int, map[int][]int) {
+	m := make(map[int][]int)
+	teams := make(map[int][]int)
+	for teamNumber, result := range results {
+		if result == nil {
+			continue
+		}
+		team := result.(costPoints)
+		m[team.price] = append(m[team.price], team.points)
+		teams[team.price] = append(teams[team.price], teamNumber)
 	}
+	return m, teams
+}
 
-	return nil
+// CostDistributionStrategy records the points and cost distribution of each simulated team
+type CostDistributionStrategy struct {
+	resolver *Resolver
 }
 
-// RunDistributionStrategy simulates random teams, and records the points and cost distribution for each
-func (r *Resolver) RunDistributionStrategy(simulatedTeams chan []database.PlayerInfo) error {
-
-	// Data channels used to store simulation results
-	resultsCh := make(chan []int, MaxQueries)
-
-	// Simulate distribution strategy in batches (Prevent MySQL connection error 1040)
-	for j := 0; j < (MaxQueries / maxBatchSize); j++ {
-
-		// Manage concurrency
-		wg := &sync.WaitGroup{}
-		wg.Add(maxBatchSize)
-
-		for i := 0; i < maxBatchSize; i++ {
-			go func() {
-				defer wg.Done()
-
-				team := <-simulatedTeams
-
-				// Calculate the overall team price.
-				// If less than 950, ignore, since not using all available funds would skew the results
-				if CalculatePrice(team) < 950 {
-					return
-				}
-
-				// Calculate the overall team points
-				teamPoints, err := r.CalculateTeamPoints(team)
-				if err != nil {
-					fmt.Println(err)
-					return
-				}
-
-				// Calculate the cost distribution of the team, then add data into appropriate channels
-				costDistribution, err := CalculateTeamDistribution(team)
-				if err != nil {
-					fmt.Println(err)
-					return
-				}
-
-				// Add the simulation results onto a channel, and recycle the team data used
-				resultsCh <- []int{costDistribution[0], teamPoints}
-				simulatedTeams <- team
-			}()
-		}
-		wg.Wait()
+// Name returns the name used to pick the strategy from the command line
+func (s *CostDistributionStrategy) Name() string {
+	return costDistributionName
+}
+
+// Description returns a summary of what the strategy tests
+func (s *CostDistributionStrategy) Description() string {
+	return "Points percentiles of the teams by how many expensive players they have"
+}
+
+// Parameters returns the captaincy policy used to score the teams, and how far under budget a team can be
+func (s *CostDistributionStrategy) Parameters() map[string]string {
+	return map[string]string{
+		"captaincy":     s.resolver.MostExpensiveCaptain().Name,
+		"budget_margin": strconv.Itoa(distributionBudgetMargin),
 	}
+}
 
-	close(resultsCh)
+// Tables returns the results file of the strategy
+func (s *CostDistributionStrategy) Tables() []ResultsTable {
+	return []ResultsTable{{File: CostDistributionFile, Header: CostDistributionHeader, Comparable: true}}
+}
 
-	// Create an array to house each category of distribution, between 0 and 10
-	distributionResults := make([][]int, 10)
+// Evaluate calculates the cost distribution and overall points of the team.
+// If more than £5M under budget the team is left out, since not using all available funds would skew the results.
+func (s *CostDistributionStrategy) Evaluate(team Squad) (interface{}, error) {
+	rules := s.resolver.ResolveRules(team.Season())
+	if team.Price() < rules.Budget-distributionBudgetMargin {
+		return nil, nil
+	}
 
-	// For each result simulated, store result in the correct array space
-	for result := range resultsCh {
-		distributionResults[result[0]] = append(distributionResults[result[0]], result[1])
+	// Calculate the overall team points
+	teamPoints, err := s.resolver.CalculateTeamPoints(team, s.resolver.MostExpensiveCaptain())
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	// Calculate the cost distribution of the team
+	costDistribution, err := CalculateTeamDistribution(team, rules)
+	if err != nil {
+		return nil, errors.Wrap(err)
 	}
 
+	return []int{costDistribution[0], teamPoints}, nil
+}
+
+// Aggregate returns a results row for each team category, labelled with the season
+func (s *CostDistributionStrategy) Aggregate(season string, results []interface{}) ([][]string, error) {
+
+	// Create an array to house each category of distribution, between 0 and 10
+	distributionResults, _ := groupByCategory(results)
+
 	// Calculate the percentiles for each team category
 	// These percentiles can then be used to plot a box chart.
-	percentiles := make([]string, 10)
+	percentiles := make([]string, len(distributionResults))
 	for key, category := range distributionResults {
 		// Find percentiles for each data category, and refactor as string to store in csv
 		percentile := findPercentiles(category)
 		strData := strings.Trim(strings.Replace(fmt.Sprint(percentile), " ", ", ", -1), "[]")
-		percentiles[key] = fmt.Sprintf("%v, %v", key, strData)
+		percentiles[key] = fmt.Sprintf("%v, %v, %v", season, key, strData)
 	}
 
-	// Empty the results file of any old data, and write the new data to the file
-	resultsFilePath := "internal/simulation_results/cost_distribution.csv"
-	if err := truncateFile(resultsFilePath); err != nil {
-		return errors.Wrap(err)
-	}
-	if err := writeToFile(resultsFilePath, fmt.Sprint(
-		"Team Category, "+
-			"5th Percentile ,"+
-			"25th Percentile, "+
-			"50th Percentile, "+
-			"75th Percentile, "+
-			"95th Percentile\n",
-	)); err != nil {
-		return errors.Wrap(err)
-	}
-	if err := writeToFile(resultsFilePath, strings.Join(percentiles, "\n")); err != nil {
-		return errors.Wrap(err)
+	return [][]string{percentiles}, nil
+}
+
+// Samples returns the points of the teams in each team category
+func (s *CostDistributionStrategy) Samples(season string, results []interface{}) []Sample {
+	distributionResults, teams := groupByCategory(results)
+	samples := make([]Sample, 0)
+	for key, category := range distributionResults {
+		samples = append(samples, Sample{File: CostDistributionFile, Season: season, Group: strconv.Itoa(key), Teams: teams[key], Values: category})
 	}
+	return samples
+}
 
-	return nil
+// groupByCategory returns the points of the teams, by how many expensive players they have, along with the index
+// of the team behind each value. There are at least 10 categories, with more added for any team with more expensive
+// players, which a bigger budget allows.
+func groupByCategory(results []interface{}) ([][]int, [][]int) {
+	distributionResults := make([][]int, 10)
+	teams := make([][]int, 10)
+
+	// For each result simulated, store result in the correct array space
+	for teamNumber, result := range results {
+		if result == nil {
+			continue
+		}
+		team := result.([]int)
+		for len(distributionResults) <= team[0] {
+			distributionResults = append(distributionResults, nil)
+			teams = append(teams, nil)
+		}
+		distributionResults[team[0]] = append(distributionResults[team[0]], team[1])
+		teams[team[0]] = append(teams[team[0]], teamNumber)
+	}
+	return distributionResults, teams
 }
 
 // CalculateTeamDistribution takes the team and calculates what tier each player fits into
-func CalculateTeamDistribution(team []database.PlayerInfo) ([]int, error) {
+func CalculateTeamDistribution(squad Squad, rules Rules) ([]int, error) {
 
 	// Level 1, Level 2, Level 3
 	costDistribution := []int{0, 0, 0}
 
-	if len(team) < 15 {
-		return nil, errors.New("Empty team returned")
+	if !squad.Complete(rules.SquadQuotas) {
+		return nil, errors.New("Incomplete team returned")
 	}
 
 	// Calculate cost distribution of each player
-	for _, goalkeeper := range team[0:2] {
+	for _, goalkeeper := range squad.Position(database.Goalkeeper) {
 		if goalkeeper.Price == 60 {
 			costDistribution[0]++
 			continue
@@ -208,7 +265,7 @@ func CalculateTeamDistribution(team []database.PlayerInfo) ([]int, error) {
 		}
 		costDistribution[2]++
 	}
-	for _, defender := range team[2:7] {
+	for _, defender := range squad.Position(database.Defender) {
 		if defender.Price >= 65 {
 			costDistribution[0]++
 			continue
@@ -219,7 +276,7 @@ func CalculateTeamDistribution(team []database.PlayerInfo) ([]int, error) {
 		}
 		costDistribution[2]++
 	}
-	for _, midfielder := range team[7:12] {
+	for _, midfielder := range squad.Position(database.Midfielder) {
 		if midfielder.Price >= 90 {
 			costDistribution[0]++
 			continue
@@ -231,7 +288,7 @@ func CalculateTeamDistribution(team []database.PlayerInfo) ([]int, error) {
 		costDistribution[2]++
 	}
 
-	for _, forward := range team[12:15] {
+	for _, forward := range squad.Position(database.Forward) {
 		if forward.Price >= 90 {
 			costDistribution[0]++
 			continue
@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"fpl-strategy-tester/internal/database"
+	"sort"
+)
+
+// Lineup is the starting XI picked from a squad for a game week, and the order of the bench.
+// The first player on the bench is the backup goalkeeper, as in FPL.
+type Lineup struct {
+	Starters []database.PlayerInfo
+	Bench    []database.PlayerInfo
+}
+
+// PlayerRanking scores a player when picking a lineup, with higher scoring players picked first
+type PlayerRanking func(player database.PlayerInfo) float64
+
+// RankByPrice ranks the players by their price, as a proxy for the points they are expected to score
+func RankByPrice(player database.PlayerInfo) float64 {
+	return float64(player.Price)
+}
+
+// PickLineup picks the highest ranked starting XI which fits the formation limits, and orders the rest
+// of the squad on the bench from highest to lowest ranked
+func PickLineup(squad Squad, rules Rules, rank PlayerRanking) Lineup {
+
+	// Sort each position from highest to lowest ranked
+	candidates := make(map[database.Position][]database.PlayerInfo)
+	for _, position := range database.Positions {
+		players := append([]database.PlayerInfo{}, squad.Position(position)...)
+		sort.SliceStable(players, func(i, j int) bool {
+			return rank(players[i]) > rank(players[j])
+		})
+		candidates[position] = players
+	}
+
+	// Start with the fewest players allowed in each position
+	lineup := Lineup{}
+	picked := make(map[database.Position]int)
+	for _, position := range database.Positions {
+		for picked[position] < rules.Formation[position].Min && picked[position] < len(candidates[position]) {
+			lineup.Starters = append(lineup.Starters, candidates[position][picked[position]])
+			picked[position]++
+		}
+	}
+
+	// Fill the rest of the starting XI with the highest ranked players, who fit in the formation
+	for len(lineup.Starters) < rules.StartingXI {
+		bestPosition := database.Position("")
+		for _, position := range database.Positions {
+			if picked[position] >= rules.Formation[position].Max || picked[position] >= len(candidates[position]) {
+				continue
+			}
+			if bestPosition == "" ||
+				rank(candidates[position][picked[position]]) > rank(candidates[bestPosition][picked[bestPosition]]) {
+				bestPosition = position
+			}
+		}
+		if bestPosition == "" {
+			break
+		}
+		lineup.Starters = append(lineup.Starters, candidates[bestPosition][picked[bestPosition]])
+		picked[bestPosition]++
+	}
+
+	// The backup goalkeeper goes first on the bench, followed by the outfield players
+	for _, goalkeeper := range candidates[database.Goalkeeper][picked[database.Goalkeeper]:] {
+		lineup.Bench = append(lineup.Bench, goalkeeper)
+	}
+	outfield := make([]database.PlayerInfo, 0)
+	for _, position := range database.Positions[1:] {
+		outfield = append(outfield, candidates[position][picked[position]:]...)
+	}
+	sort.SliceStable(outfield, func(i, j int) bool {
+		return rank(outfield[i]) > rank(outfield[j])
+	})
+	lineup.Bench = append(lineup.Bench, outfield...)
+
+	return lineup
+}
+
+// ValidFormation checks the starting players fit within the formation limits
+func ValidFormation(starters []database.PlayerInfo, rules Rules) bool {
+	if len(starters) != rules.StartingXI {
+		return false
+	}
+
+	counts := make(map[database.Position]int)
+	for _, player := range starters {
+		counts[player.Position]++
+	}
+	for _, position := range database.Positions {
+		if counts[position] < rules.Formation[position].Min || counts[position] > rules.Formation[position].Max {
+			return false
+		}
+	}
+	return true
+}
+
+// AutoSubstitute replaces each starter who didn't play with the first player on the bench who did, and who
+// keeps the formation valid. As in FPL, a goalkeeper can only be replaced by the backup goalkeeper.
+func AutoSubstitute(lineup Lineup, rules Rules, played func(player database.PlayerInfo) bool) Lineup {
+	starters := append([]database.PlayerInfo{}, lineup.Starters...)
+	bench := append([]database.PlayerInfo{}, lineup.Bench...)
+	used := make([]bool, len(bench))
+
+	for key, starter := range starters {
+		if played(starter) {
+			continue
+		}
+
+		for i, substitute := range bench {
+			if used[i] || !played(substitute) {
+				continue
+			}
+			if (starter.Position == database.Goalkeeper) != (substitute.Position == database.Goalkeeper) {
+				continue
+			}
+
+			// Only make the substitution if the new formation is still valid
+			starters[key] = substitute
+			if !ValidFormation(starters, rules) {
+				starters[key] = starter
+				continue
+			}
+
+			bench[i] = starter
+			used[i] = true
+			break
+		}
+	}
+
+	return Lineup{Starters: starters, Bench: bench}
+}
@@ -0,0 +1,257 @@
+package internal
+
+import (
+	"fpl-strategy-tester/internal/database"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/icelolly/go-errors"
+)
+
+/*	MANAGERS:
+	This file of code holds the manager policies, which make the decisions for a team
+	each game week of a simulated season.
+*/
+
+// SetAndForgetManager keeps the squad picked at the start of the season, making no transfers and playing
+// no chips. Each game week the lineup is picked by price, and the captain by the captaincy policy.
+type SetAndForgetManager struct {
+	Captaincy CaptaincyPolicy
+}
+
+// Name returns the name of the manager, used to label their results
+func (m SetAndForgetManager) Name() string {
+	return "Set and Forget"
+}
+
+// Decide picks the lineup and captains for the game week
+func (m SetAndForgetManager) Decide(state SeasonState) (Decisions, error) {
+	lineup := PickLineup(state.Squad, state.Rules, RankByPrice)
+	captains := PickCaptains(lineup, state.GW, m.Captaincy, state.Rules.CaptainMultiplier)
+	return Decisions{
+		Lineup:      lineup,
+		Captain:     captains.Captain,
+		ViceCaptain: captains.ViceCaptain,
+	}, nil
+}
+
+// TransferManager makes the transfers with the best projected gain each game week, judging players by their
+// points over the last few game weeks. Free transfers are made whenever they are projected to gain points.
+// When TakeHits is set, further transfers are made while their projected gain is more than the hit they cost.
+type TransferManager struct {
+	resolver  *Resolver
+	captaincy CaptaincyPolicy
+	takeHits  bool
+	rankings  *formRankings
+}
+
+// formRankings caches the players of each position ranked by form, as they are the same for every team
+type formRankings struct {
+	mutex    sync.Mutex
+	rankings map[formKey][]rankedPlayer
+}
+
+// formKey identifies the players of a position in a game week
+type formKey struct {
+	season   string
+	gw       int
+	position database.Position
+}
+
+// rankedPlayer is a player along with their form going into a game week
+type rankedPlayer struct {
+	player database.PlayerInfo
+	form   int
+}
+
+// NewTransferManager creates a manager which makes transfers, and which takes hits for them if takeHits is set
+func NewTransferManager(resolver *Resolver, captaincy CaptaincyPolicy, takeHits bool) TransferManager {
+	return TransferManager{
+		resolver:  resolver,
+		captaincy: captaincy,
+		takeHits:  takeHits,
+		rankings:  &formRankings{rankings: make(map[formKey][]rankedPlayer)},
+	}
+}
+
+// Name returns the name of the manager, used to label their results
+func (m TransferManager) Name() string {
+	if m.takeHits {
+		return "Hit When Gain Beats Cost"
+	}
+	return "Never Take Hits"
+}
+
+// Decide makes the transfers for the game week, then picks the lineup and captains from the new squad
+func (m TransferManager) Decide(state SeasonState) (Decisions, error) {
+	decisions := Decisions{}
+
+	// The squad has only just been picked for the first game week
+	squad := state.Squad
+	if state.GW > 1 {
+		transfers, err := m.pickTransfers(state, "")
+		if err != nil {
+			return Decisions{}, errors.Wrap(err)
+		}
+		decisions.Transfers = transfers
+		squad = applyTransfers(squad, transfers)
+	}
+
+	decisions.Lineup = PickLineup(squad, state.Rules, RankByPrice)
+	captains := PickCaptains(decisions.Lineup, state.GW, m.captaincy, state.Rules.CaptainMultiplier)
+	decisions.Captain, decisions.ViceCaptain = captains.Captain, captains.ViceCaptain
+	return decisions, nil
+}
+
+// pickTransfers repeatedly makes the transfer with the best projected gain, until the next transfer isn't
+// worth making. Every transfer is free when the chip is a Wildcard or Free Hit, and a Free Hit squad is only
+// picked for the one game week, so players without a fixture are left out.
+func (m TransferManager) pickTransfers(state SeasonState, chip Chip) ([]Transfer, error) {
+	points, ok := m.resolver.Points[state.Rules.Season]
+	if !ok {
+		return nil, errors.New("No points loaded for season: " + state.Rules.Season)
+	}
+
+	// A transfer is expected to keep paying back for a few game weeks, but no further than the end of the season
+	projection := points.Gameweeks() - state.GW + 1
+	if projection > formGameweeks {
+		projection = formGameweeks
+	}
+	freeTransfers := state.FreeTransfers
+	if chip == Wildcard || chip == FreeHit {
+		freeTransfers = state.Squad.Size()
+	}
+	if chip == FreeHit {
+		projection = 1
+	}
+
+	players := state.Squad.Players()
+	bank := state.Bank
+	transfers := make([]Transfer, 0)
+	for {
+		transfer, formGain, err := m.bestTransfer(players, bank, state, chip == FreeHit)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		gain := float64(formGain*projection) / float64(formGameweeks)
+
+		// Free transfers are made for any gain, and further transfers only when they outweigh the hit
+		if len(transfers) < freeTransfers {
+			if gain <= 0 {
+				break
+			}
+		} else if !m.takeHits || gain <= float64(state.Rules.Transfers.HitCost) {
+			break
+		}
+
+		for key, player := range players {
+			if player.ID == transfer.Out.ID {
+				bank += m.resolver.SellingPrice(player, state.GW, state.Rules) -
+					m.resolver.playerValue(transfer.In, state.GW)
+				players[key] = transfer.In
+			}
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, nil
+}
+
+// bestTransfer finds the affordable transfer which gives the biggest rise in form, keeping within the club limit.
+// It returns the rise in form, which is zero when no transfer improves the squad. When fixturesOnly is set, players
+// without a fixture in the game week count as having no form, and aren't transferred in.
+func (m TransferManager) bestTransfer(
+	players []database.PlayerInfo, bank int, state SeasonState, fixturesOnly bool,
+) (Transfer, int, error) {
+	inSquad := make(map[database.PlayerID]bool)
+	clubCounts := make(map[int]int)
+	for _, player := range players {
+		inSquad[player.ID] = true
+		clubCounts[player.Team]++
+	}
+
+	best := Transfer{}
+	bestGain := 0
+	for _, out := range players {
+		rankings, err := m.rankPosition(state.Rules.Season, state.GW, out.Position)
+		if err != nil {
+			return Transfer{}, 0, errors.Wrap(err)
+		}
+		outForm := m.resolver.pointsScored(out, state.GW-formGameweeks, state.GW-1)
+		if fixturesOnly && m.resolver.fixtureCount(out, state.GW) == 0 {
+			outForm = 0
+		}
+		budget := bank + m.resolver.SellingPrice(out, state.GW, state.Rules)
+
+		// The players are ranked by form, so the first suitable player is the best replacement
+		for _, in := range rankings {
+			if in.form-outForm <= bestGain {
+				break
+			}
+			if inSquad[in.player.ID] ||
+				(fixturesOnly && m.resolver.fixtureCount(in.player, state.GW) == 0) ||
+				(in.player.Team != out.Team && clubCounts[in.player.Team] >= state.Rules.ClubLimit) ||
+				m.resolver.playerValue(in.player, state.GW) > budget {
+				continue
+			}
+			best = Transfer{Out: out, In: in.player}
+			bestGain = in.form - outForm
+			break
+		}
+	}
+
+	return best, bestGain, nil
+}
+
+// rankPosition returns the players of the position ranked by their form going into the game week,
+// from best to worst
+func (m TransferManager) rankPosition(season string, gw int, position database.Position) ([]rankedPlayer, error) {
+	m.rankings.mutex.Lock()
+	defer m.rankings.mutex.Unlock()
+
+	key := formKey{season: season, gw: gw, position: position}
+	if rankings, ok := m.rankings.rankings[key]; ok {
+		return rankings, nil
+	}
+
+	players, err := m.resolver.Database.GetPositionPlayers(season, position)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	rankings := make([]rankedPlayer, 0, len(players))
+	for _, player := range players {
+		rankings = append(rankings, rankedPlayer{
+			player: player,
+			form:   m.resolver.pointsScored(player, gw-formGameweeks, gw-1),
+		})
+	}
+	sort.SliceStable(rankings, func(i, j int) bool {
+		return rankings[i].form > rankings[j].form
+	})
+
+	m.rankings.rankings[key] = rankings
+	return rankings, nil
+}
+
+// applyTransfers returns the squad after the transfers have been made
+func applyTransfers(squad Squad, transfers []Transfer) Squad {
+	players := squad.Players()
+	for _, transfer := range transfers {
+		for key, player := range players {
+			if player.ID == transfer.Out.ID {
+				players[key] = transfer.In
+			}
+		}
+	}
+	return NewSquad(players)
+}
+
+// managerNames returns the names of the managers, separated by commas
+func managerNames(managers []Manager) string {
+	names := make([]string, len(managers))
+	for key, manager := range managers {
+		names[key] = manager.Name()
+	}
+	return strings.Join(names, ", ")
+}
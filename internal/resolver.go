@@ -1,26 +1,29 @@
 package internal
 
 import (
+	"context"
 	"fmt"
 	"fpl-strategy-tester/internal/database"
 	"math/rand"
 	"os"
-	"strconv"
+	"runtime"
+	"strings"
 	"sync"
-	"time"
 
 	"github.com/icelolly/go-errors"
-	"github.com/patrickmn/go-cache"
 )
 
 // How many simulations to run
 const MaxQueries int = 10000
-const maxBatchSize int = 50
 
 // Resolver is the entry-point for accessing the football data
 type Resolver struct {
-	Database *database.Resolver
-	Cache    *cache.Cache
+	Database  database.PlayerRepository
+	Points    map[string]*database.PointsMatrix
+	Calendars map[string]*database.FixtureCalendar
+	Rules     map[string]Rules
+	Workers   int
+	Seed      int64
 }
 
 // NewResolver creates and returns an empty Resolver
@@ -28,184 +31,331 @@ func NewResolver() *Resolver {
 	return &Resolver{}
 }
 
-// ResolveDatabase returns or initiates a new database connection
-func (r *Resolver) ResolveDatabase() *database.Resolver {
+// ResolveDatabase returns the player repository, or initiates a new database connection if none has been set
+func (r *Resolver) ResolveDatabase(config database.Config) (database.PlayerRepository, error) {
 	if r.Database == nil {
-		repo := database.NewResolver()
-		repo.ResolveFPLDB()
-		repo.ResolveMySQLQueryBuilder()
+		repo := database.NewResolver(config)
+		if _, err := repo.ResolveFPLDB(); err != nil {
+			return nil, errors.Wrap(err)
+		}
+		repo.ResolveQueryBuilder()
 		r.Database = repo
 	}
-	return r.Database
+	return r.Database, nil
+}
+
+// ResolveWorkers returns the pool of workers used to simulate the teams and run the strategies. Unless the
+// number of workers has been set, there is a worker for each database connection, or for each CPU when the
+// player data is held in memory.
+func (r *Resolver) ResolveWorkers() WorkerPool {
+	if r.Workers > 0 {
+		return NewWorkerPool(r.Workers)
+	}
+	if repo, ok := r.Database.(*database.Resolver); ok {
+		return NewWorkerPool(repo.MaxOpenConns())
+	}
+	return NewWorkerPool(runtime.NumCPU())
+}
+
+// ResolvePoints loads the game week points of every player in the season into memory,
+// or re-uses the existing points matrix
+func (r *Resolver) ResolvePoints(season string) (*database.PointsMatrix, error) {
+	if r.Points == nil {
+		r.Points = make(map[string]*database.PointsMatrix)
+	}
+	if _, ok := r.Points[season]; !ok {
+		gwData, err := r.Database.GetAllPlayerData(season)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		r.Points[season] = database.NewPointsMatrix(gwData)
+	}
+	return r.Points[season], nil
+}
+
+// ResolveCalendar loads the fixtures of every club in the season into memory, or re-uses the existing calendar
+func (r *Resolver) ResolveCalendar(season string) (*database.FixtureCalendar, error) {
+	if r.Calendars == nil {
+		r.Calendars = make(map[string]*database.FixtureCalendar)
+	}
+	if _, ok := r.Calendars[season]; !ok {
+		gwData, err := r.Database.GetAllPlayerData(season)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		r.Calendars[season] = database.NewFixtureCalendar(gwData)
+	}
+	return r.Calendars[season], nil
+}
+
+// GetPlayerGameweeks returns the player's data for each game week played in the season, with the fixtures of any
+// double game week grouped together, and a blank for any game week without a fixture
+func (r *Resolver) GetPlayerGameweeks(season string, playerID database.PlayerID) ([]database.PlayerGameweek, error) {
+	calendar, err := r.ResolveCalendar(season)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	gwData, err := r.Database.GetPlayerData(season, playerID)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	return database.GroupByGameweek(gwData, calendar.PlayedGameweeks()), nil
 }
 
-// ResolveCache creates a new, or re-uses an existing cache instance
-func (r *Resolver) ResolveCache() *cache.Cache {
-	if r.Cache == nil {
-		r.Cache = cache.New(5*time.Minute, 10*time.Minute)
+// ResolveRules returns the FPL rules set for the season, or the rules used in that season if none have been set
+func (r *Resolver) ResolveRules(season string) Rules {
+	if rules, ok := r.Rules[season]; ok {
+		return rules
 	}
-	return r.Cache
+	return SeasonRules(season)
 }
 
-// GenerateTeams simulates 10,000 possible teams, and returns them on a channel for the results
-// to be analysed by the different strategies.
-func (r *Resolver) GenerateTeams(resultsCh chan []database.PlayerInfo, errCh chan error) {
+// The maximum number of times a team which couldn't be picked is tried again, before giving up on the pool
+const maxTeamAttempts int = 5
 
-	// Simulate distribution strategy in batches (Prevent MySQL connection error 1040)
-	for j := 0; j < (MaxQueries / maxBatchSize); j++ {
+// GenerateTeams simulates 10,000 possible teams from the season's players, for the results to be analysed by
+// the different strategies. Any team which can't be picked is tried again, so the pool always holds exactly
+// MaxQueries teams, or an error is returned. It reports how many of the teams had to be repaired or picked
+// again to follow the FPL squad rules.
+func (r *Resolver) GenerateTeams(ctx context.Context, season string) (*TeamPool, GenerationReport, error) {
 
-		// Manage concurrency
-		wg := &sync.WaitGroup{}
-		wg.Add(maxBatchSize)
+	rules := r.ResolveRules(season)
+	if err := ValidateRules(rules, r.Database); err != nil {
+		return nil, GenerationReport{}, errors.Wrap(err)
+	}
+	teams := make([]Squad, MaxQueries)
+	report := GenerationReport{}
+	reportMutex := &sync.Mutex{}
+	var failure error
+
+	// Stop simulating teams as soon as one of them can't be picked, as the pool can't be filled
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := r.ResolveWorkers().Run(ctx, MaxQueries, func(ctx context.Context, teamNumber int) error {
+
+		// Each team has its own random numbers, so is the same however the workers are scheduled
+		rng := TeamRand(r.Seed, season, teamNumber)
 
-		for i := 0; i < maxBatchSize; i++ {
-			go func() {
-				defer wg.Done()
+		var err error
+		for attempt := 1; attempt <= maxTeamAttempts; attempt++ {
+			if ctx.Err() != nil {
+				return errors.Wrap(ctx.Err())
+			}
+
+			// Create a random team value to simulate, in £1M steps (between £75M & £100M by default)
+			randomTeamValue := rules.MinTeamValue
+			if steps := (rules.Budget - rules.MinTeamValue) / 10; steps > 0 {
+				randomTeamValue += rng.Intn(steps) * 10
+			}
 
-				// Create a random team value to simulate (between £75M & £100M)
-				randomTeamValue := rand.Intn(100-75) + 75
+			// Simulate a random FPL team, up to the maximum value
+			var team Squad
+			var teamReport TeamReport
+			team, teamReport, err = r.PickRandomTeam(ctx, rng, season, randomTeamValue)
 
-				// Simulate a random FPL team, up to the maximum value
-				if team, err := r.PickRandomTeam(randomTeamValue * 10); err != nil {
-					errCh <- err
-				} else {
-					resultsCh <- team
-				}
-			}()
+			reportMutex.Lock()
+			if err == nil {
+				report.add(teamReport)
+			} else {
+				report.Failed++
+			}
+			reportMutex.Unlock()
+
+			if err == nil {
+				teams[teamNumber] = team
+				return nil
+			}
 		}
-		wg.Wait()
+		reportMutex.Lock()
+		if failure == nil {
+			failure = errors.Wrap(err)
+		}
+		reportMutex.Unlock()
+		cancel()
+		return errors.Wrap(err)
+	})
+
+	// Report the team which couldn't be picked, rather than the teams stopped because of it
+	if failure != nil {
+		return nil, report, failure
 	}
+	if len(errs) > 0 {
+		return nil, report, errs[0]
+	}
+	if ctx.Err() != nil {
+		return nil, report, errors.Wrap(ctx.Err())
+	}
+
+	return newTeamPool(season, r.Seed, teams), report, nil
 }
 
-// PickRandomTeam creates a random team from the player selections available in GW1
-// It takes the maximum value a team can be, and returns a team equal to that value
-func (r *Resolver) PickRandomTeam(maxValue int) ([]database.PlayerInfo, error) {
+// The maximum number of times a team is thrown away and picked again, when it can't be repaired
+const maxResamples int = 10
+
+// TeamReport records what was needed to make a randomly picked team follow the FPL squad rules
+type TeamReport struct {
+	Repairs   int
+	Resamples int
+}
+
+// GenerationReport counts how many of the generated teams broke the FPL squad rules when first picked, and how
+// many attempts at picking a team failed and were tried again
+type GenerationReport struct {
+	Teams     int
+	Repaired  int
+	Resampled int
+	Failed    int
+}
 
-	// The minimum value a team can be is £75M
-	if maxValue < 750 {
-		return nil, errors.New("Unable to create a team - team value too low")
+// add includes the team in the report
+func (g *GenerationReport) add(team TeamReport) {
+	g.Teams++
+	if team.Repairs > 0 {
+		g.Repaired++
 	}
+	if team.Resamples > 0 {
+		g.Resampled++
+	}
+}
 
-	// Create an empty team
-	teamSelection := make([]database.PlayerInfo, 0)
+// PickRandomTeam creates a random team from the player selections available in GW1 of the season
+// It takes the maximum value a team can be, and returns a team equal to that value. Any team which
+// breaks the FPL squad rules is repaired, or thrown away and picked again. Every random choice is made
+// using the random number generator. Picking stops when the context is cancelled.
+func (r *Resolver) PickRandomTeam(ctx context.Context, rng *rand.Rand, season string, maxValue int) (Squad, TeamReport, error) {
+	rules := r.ResolveRules(season)
 
-	// Select and add two random goalkeepers to the team
-	for i := 0; i < 2; i++ {
-		selectedPlayer, err := r.Database.GetRandomPlayer("G")
-		if err != nil {
-			return nil, errors.Wrap(err)
-		}
-		teamSelection = append(teamSelection, selectedPlayer)
+	// The minimum value a team can be is £75M by default
+	if maxValue < rules.MinTeamValue {
+		return Squad{}, TeamReport{}, errors.New("Unable to create a team - team value too low")
 	}
 
-	// Select and add five random defenders to the team
-	for i := 0; i < 5; i++ {
-		player, err := r.Database.GetRandomPlayer("D")
+	report := TeamReport{}
+	for attempt := 0; attempt <= maxResamples; attempt++ {
+		teamSelection, err := r.pickTeam(ctx, rng, season, maxValue, rules)
 		if err != nil {
-			return nil, errors.Wrap(err)
+			return Squad{}, report, errors.Wrap(err)
 		}
-		teamSelection = append(teamSelection, player)
-	}
 
-	// Select and add five random midfielders to the team
-	for i := 0; i < 5; i++ {
-		player, err := r.Database.GetRandomPlayer("M")
-		if err != nil {
-			return nil, errors.Wrap(err)
+		// Replace any players breaking the rules, and check the repaired team is legal
+		repairs, err := r.repairTeam(teamSelection, rules)
+		report.Repairs += repairs
+		if err == nil {
+			squad := NewSquad(teamSelection)
+			if violations := ValidateSquad(squad, rules); len(violations) == 0 {
+				return squad, report, nil
+			}
 		}
-		teamSelection = append(teamSelection, player)
+		report.Resamples++
 	}
 
-	// Select and add three random forwards to the team
-	for i := 0; i < 3; i++ {
-		player, err := r.Database.GetRandomPlayer("F")
-		if err != nil {
-			return nil, errors.Wrap(err)
+	return Squad{}, report, errors.New("Unable to create a team - no legal team found")
+}
+
+// pickTeam selects random players for each position, then upgrades and downgrades them until
+// the team is worth the maximum value. An error is returned once none of the players can be upgraded or
+// downgraded any further.
+func (r *Resolver) pickTeam(ctx context.Context, rng *rand.Rand, season string, maxValue int, rules Rules) ([]database.PlayerInfo, error) {
+
+	// Create an empty team
+	teamSelection := make([]database.PlayerInfo, 0)
+
+	// Select and add random players to the team, until each position is filled
+	for _, position := range database.Positions {
+		for i := 0; i < rules.SquadQuotas[position]; i++ {
+			player, err := r.Database.GetRandomPlayer(rng, season, position, rules.StarterPrice)
+			if err != nil {
+				return nil, errors.Wrap(err)
+			}
+			teamSelection = append(teamSelection, player)
 		}
-		teamSelection = append(teamSelection, player)
 	}
 
-	// While the team's value remains under the maximum value, continue to upgrade random players in the team
+	// While the team's value remains under the maximum value, continue to upgrade random players in the team.
+	// Players who can't be upgraded are remembered, so picking stops once none of them can be.
+	maxedOut := make(map[int]bool)
 	for CalculatePrice(teamSelection) < maxValue {
-		randomPlayer := rand.Intn(len(teamSelection))
+		if ctx.Err() != nil {
+			return nil, errors.Wrap(ctx.Err())
+		}
+		if len(maxedOut) == len(teamSelection) {
+			return nil, errors.New(fmt.Sprintf("Unable to create a team - no upgrade reaches the team value %v", maxValue))
+		}
+		randomPlayer := rng.Intn(len(teamSelection))
 
-		if playerUpgrade, err := r.Database.UpgradePlayer(teamSelection[randomPlayer]); err != nil {
-			fmt.Printf("Error occured while attempting to upgrade player: %v\n", err)
+		if playerUpgrade, err := r.Database.UpgradePlayer(rng, teamSelection[randomPlayer]); err != nil {
+			maxedOut[randomPlayer] = true
 		} else {
 			teamSelection[randomPlayer] = playerUpgrade
+			delete(maxedOut, randomPlayer)
 		}
 	}
 
-	// If the team's value exceeds the £100M budget, continue to downgrade random players in the team
-	for CalculatePrice(teamSelection) > 1000 {
-		randomPlayer := rand.Intn(len(teamSelection))
+	// If the team's value exceeds the budget, continue to downgrade random players in the team
+	cheapest := make(map[int]bool)
+	for CalculatePrice(teamSelection) > rules.Budget {
+		if ctx.Err() != nil {
+			return nil, errors.Wrap(ctx.Err())
+		}
+		if len(cheapest) == len(teamSelection) {
+			return nil, errors.New(fmt.Sprintf("Unable to create a team - no downgrade reaches the budget %v", rules.Budget))
+		}
+		randomPlayer := rng.Intn(len(teamSelection))
 
 		if playerDowngrade, err := r.Database.DowngradePlayer(teamSelection[randomPlayer]); err != nil {
-			fmt.Printf("Error occured while attempting to upgrade player: %v\n", err)
+			cheapest[randomPlayer] = true
 		} else {
 			teamSelection[randomPlayer] = playerDowngrade
-		}
-	}
-
-	// Cycle through each player in the team to check for any rules broken
-	teamCriteria := false
-	for !teamCriteria {
-		duplicatePlayers := false
-
-		// Check that there are no duplicate players in the team
-		for key, player := range teamSelection {
-			for i := key + 1; i < len(teamSelection); i++ {
-				if player.ID == teamSelection[i].ID {
-					duplicatePlayers = true
-
-					// Replace the duplicate player with an equivalent alternative
-					replacementPlayer, err := r.Database.ReplacePlayer(player, teamSelection)
-					if err != nil {
-						fmt.Printf("Error while replacing player: %v\n", err)
-						continue
-					}
-					teamSelection[i] = replacementPlayer
-				}
-			}
-		}
-		if !duplicatePlayers {
-			teamCriteria = true
+			delete(cheapest, randomPlayer)
 		}
 	}
 
 	return teamSelection, nil
 }
 
-// CalculateTeamPoints takes the team of players and returns a total of their end-of-season points
-func (r *Resolver) CalculateTeamPoints(team []database.PlayerInfo) (int, error) {
+// repairTeam replaces any duplicate players, and any players above the club limit, with an equally priced
+// alternative. It returns how many players were replaced.
+func (r *Resolver) repairTeam(teamSelection []database.PlayerInfo, rules Rules) (int, error) {
 
-	teamPoints := 0
-	for _, player := range team {
+	clubCounts := make(map[int]int)
+	for _, player := range teamSelection {
+		clubCounts[player.Team]++
+	}
 
-		// Check if the player points have already been calculated
-		playerPoints, found := r.Cache.Get(strconv.Itoa(player.ID))
+	repairs := 0
+	for key, player := range teamSelection {
 
-		// If the player data has not yet been calculated, perform the calculation and store in cache
-		if !found {
-			// Get the 38 weeks of player data
-			gwData, err := r.Database.GetPlayerData(player.ID)
-			if err != nil {
-				return 0, errors.Wrap(err)
+		// Check whether the player has already been picked, or their club has too many players
+		duplicatePlayer := false
+		for i := 0; i < key; i++ {
+			if teamSelection[i].ID == player.ID {
+				duplicatePlayer = true
 			}
+		}
+		if !duplicatePlayer && clubCounts[player.Team] <= rules.ClubLimit {
+			continue
+		}
 
-			pointsTotal := 0
-			for _, gw := range gwData {
-				pointsTotal += gw.TotalPoints
+		// Replace the player with an equivalent alternative, from a club with space in the team
+		excludedClubs := make([]int, 0)
+		for club, count := range clubCounts {
+			if count >= rules.ClubLimit {
+				excludedClubs = append(excludedClubs, club)
 			}
-			playerPoints = pointsTotal
-
-			// Save the value in cache
-			r.Cache.Set(strconv.Itoa(player.ID), pointsTotal, cache.DefaultExpiration)
 		}
-		teamPoints += playerPoints.(int)
+		replacementPlayer, err := r.Database.ReplacePlayer(player, teamSelection, excludedClubs)
+		if err != nil {
+			return repairs, errors.Wrap(err)
+		}
+
+		clubCounts[player.Team]--
+		clubCounts[replacementPlayer.Team]++
+		teamSelection[key] = replacementPlayer
+		repairs++
 	}
 
-	return teamPoints, nil
+	return repairs, nil
 }
 
 // CalculatePrice takes the team info and return's the combined worth of all players
@@ -217,9 +367,34 @@ func CalculatePrice(team []database.PlayerInfo) int {
 	return teamPrice
 }
 
+// WriteResultsFile empties the results file of any old data, and writes the header and rows into it
+func WriteResultsFile(filePath, header string, rows []string) error {
+	if err := truncateFile(filePath); err != nil {
+		return errors.Wrap(err)
+	}
+	if err := writeToFile(filePath, header); err != nil {
+		return errors.Wrap(err)
+	}
+	if err := writeToFile(filePath, strings.Join(rows, "\n")); err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+// AppendResults writes the rows to the end of a results file, which already holds its header
+func AppendResults(filePath string, rows []string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := writeToFile(filePath, strings.Join(rows, "\n")+"\n"); err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
 // truncateFile empties the desired file, ready for new data
 func truncateFile(filePath string) error {
-	f, err := os.OpenFile(filePath, os.O_TRUNC, 0666)
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
 	if err != nil {
 		return errors.Wrap(err)
 	}
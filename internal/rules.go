@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"fpl-strategy-tester/internal/database"
+	"os"
+
+	"github.com/icelolly/go-errors"
+)
+
+// Chip is one of the FPL chips, which give a one-off boost when played
+type Chip string
+
+// The chips which have been available in FPL. The Assistant Manager chip of 2024-25 is left out, as it scores
+// the points of a Premier League manager, which aren't in the dataset.
+const (
+	Wildcard      Chip = "wildcard"
+	FreeHit       Chip = "freehit"
+	BenchBoost    Chip = "bboost"
+	TripleCaptain Chip = "3xc"
+)
+
+// FormationLimits is the fewest and most players a starting XI can have in a position
+type FormationLimits struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// TransferRules are the rules for making transfers between game weeks
+type TransferRules struct {
+	// FreeTransfers is the number of free transfers given each game week
+	FreeTransfers int `json:"free_transfers"`
+	// MaxFreeTransfers is the most free transfers which can be saved up by rolling them over
+	MaxFreeTransfers int `json:"max_free_transfers"`
+	// HitCost is the points deducted for each transfer above the free transfers
+	HitCost int `json:"hit_cost"`
+	// SellingProfitShare is the share of a player's price rise kept when selling them
+	SellingProfitShare float64 `json:"selling_profit_share"`
+}
+
+// Rules are the FPL rules for a season. Prices are in units of £0.1M, as in the 'GW1' table.
+type Rules struct {
+	Season      string                                `json:"season"`
+	Budget      int                                   `json:"budget"`
+	SquadQuotas map[database.Position]int             `json:"squad_quotas"`
+	ClubLimit   int                                   `json:"club_limit"`
+	StartingXI  int                                   `json:"starting_xi"`
+	Formation   map[database.Position]FormationLimits `json:"formation"`
+	Chips       map[Chip]int                          `json:"chips"`
+	Transfers   TransferRules                         `json:"transfers"`
+
+	// ChipsResetGameweek is the game week from which the chips are given again for the second half of the
+	// season, with any chips left from the first half lost. It is zero when the chips last the whole season.
+	ChipsResetGameweek int `json:"chips_reset_gameweek"`
+
+	// CaptainMultiplier is how many times the captain's points are counted, or with the Triple Captain chip
+	CaptainMultiplier       int `json:"captain_multiplier"`
+	TripleCaptainMultiplier int `json:"triple_captain_multiplier"`
+
+	// MinTeamValue is the lowest value of the random teams simulated
+	MinTeamValue int `json:"min_team_value"`
+	// StarterPrice is the most a player can cost when first picked for a random team
+	StarterPrice int `json:"starter_price"`
+}
+
+// SeasonRules returns the FPL rules used in the season
+func SeasonRules(season string) Rules {
+	rules := Rules{
+		Season: season,
+		Budget: 1000,
+		SquadQuotas: map[database.Position]int{
+			database.Goalkeeper: 2,
+			database.Defender:   5,
+			database.Midfielder: 5,
+			database.Forward:    3,
+		},
+		ClubLimit:  3,
+		StartingXI: 11,
+		Formation: map[database.Position]FormationLimits{
+			database.Goalkeeper: {Min: 1, Max: 1},
+			database.Defender:   {Min: 3, Max: 5},
+			database.Midfielder: {Min: 2, Max: 5},
+			database.Forward:    {Min: 1, Max: 3},
+		},
+		Chips: map[Chip]int{
+			Wildcard:      2,
+			FreeHit:       1,
+			BenchBoost:    1,
+			TripleCaptain: 1,
+		},
+		Transfers: TransferRules{
+			FreeTransfers:      1,
+			MaxFreeTransfers:   2,
+			HitCost:            4,
+			SellingProfitShare: 0.5,
+		},
+		CaptainMultiplier:       2,
+		TripleCaptainMultiplier: 3,
+		MinTeamValue:            750,
+		StarterPrice:            50,
+	}
+
+	switch {
+	case season <= "2016-17":
+		// The Free Hit chip was introduced in 2017-18
+		delete(rules.Chips, FreeHit)
+	case season == "2024-25":
+		// Up to five free transfers could be saved. The Assistant Manager chip was also introduced, but can't be
+		// simulated without the managers' points, so is left out.
+		rules.Transfers.MaxFreeTransfers = 5
+	case season >= "2025-26":
+		// Every chip can be played once in each half of the season, with the second set given from game week 20
+		rules.Transfers.MaxFreeTransfers = 5
+		rules.Chips = map[Chip]int{
+			Wildcard:      1,
+			FreeHit:       1,
+			BenchBoost:    1,
+			TripleCaptain: 1,
+		}
+		rules.ChipsResetGameweek = 20
+	}
+
+	return rules
+}
+
+// ReadRules reads a JSON rules file, which overrides the rules of the season, and checks the season's players
+// can be simulated under them. Any rules missing from the file keep their value for the season, so a what-if can
+// be as small as '{"budget": 1050}'.
+func ReadRules(filePath, season string, repo database.PlayerRepository) (Rules, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return Rules{}, errors.Wrap(err)
+	}
+	defer file.Close()
+
+	rules := SeasonRules(season)
+	if err := json.NewDecoder(file).Decode(&rules); err != nil {
+		return Rules{}, errors.Wrap(err)
+	}
+	rules.Season = season
+	if err := ValidateRules(rules, repo); err != nil {
+		return Rules{}, errors.Wrap(err)
+	}
+	return rules, nil
+}
+
+// ValidateRules checks the rules can be used to simulate teams from the season's players, with a budget no lower
+// than the lowest value of the random teams, and no higher than the price of the most expensive squad
+func ValidateRules(rules Rules, repo database.PlayerRepository) error {
+	if rules.Budget < rules.MinTeamValue {
+		return errors.New(fmt.Sprintf("Budget %v is below the minimum team value %v", rules.Budget, rules.MinTeamValue))
+	}
+
+	// The most expensive squad takes the most expensive players in each position, whatever their club
+	maxPrice := 0
+	for _, position := range database.Positions {
+		players, err := repo.GetPositionPlayers(rules.Season, position)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		quota := rules.SquadQuotas[position]
+		if quota > len(players) {
+			return errors.New(fmt.Sprintf("Only %v players in position %v, fewer than the squad quota %v",
+				len(players), position, quota))
+		}
+		for _, player := range players[len(players)-quota:] {
+			maxPrice += player.Price
+		}
+	}
+	if rules.Budget > maxPrice {
+		return errors.New(fmt.Sprintf("Budget %v can't be reached, the most expensive squad costs %v",
+			rules.Budget, maxPrice))
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"fpl-strategy-tester/internal/database"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icelolly/go-errors"
+)
+
+/*	RUNS:
+	This file of code keeps the results of every run in a directory of its own.
+	Alongside the results files, each run directory holds a manifest recording
+	everything needed to understand and repeat the run, so the results of two runs
+	can be compared.
+*/
+
+// RunsDir is the directory holding a directory for each run
+const RunsDir = "internal/simulation_results/runs"
+
+// ManifestFile is the name of the manifest within a run directory
+const ManifestFile = "manifest.json"
+
+// runIDFormat is the layout of the timestamp used to name each run directory
+const runIDFormat = "2006-01-02T15-04-05"
+
+// DataSource is where the player data of a run was loaded from. The fingerprint changes whenever the data does.
+type DataSource struct {
+	Kind        string `json:"kind"`
+	Location    string `json:"location"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// StrategyRun is a strategy run against the teams, with the parameters it was run with and its results files.
+// Dropped counts the teams of each season the strategy failed to evaluate, which are missing from its results.
+type StrategyRun struct {
+	Name       string            `json:"name"`
+	Parameters map[string]string `json:"parameters"`
+	Files      []string          `json:"files"`
+	Dropped    map[string]int    `json:"dropped,omitempty"`
+}
+
+// StepTiming is how long a step of the run took, such as generating a season's teams or running a strategy
+type StepTiming struct {
+	Season  string  `json:"season"`
+	Step    string  `json:"step"`
+	Seconds float64 `json:"seconds"`
+}
+
+// Manifest records how a run was made, and is written into the run's directory alongside its results
+type Manifest struct {
+	ID          string           `json:"id"`
+	Started     time.Time        `json:"started"`
+	Finished    time.Time        `json:"finished"`
+	Seconds     float64          `json:"seconds"`
+	Seed        int64            `json:"seed"`
+	Teams       int              `json:"teams"`
+	Workers     int              `json:"workers"`
+	Seasons     []string         `json:"seasons"`
+	Rules       map[string]Rules `json:"rules"`
+	Strategies  []StrategyRun    `json:"strategies"`
+	DataSource  DataSource       `json:"data_source"`
+	CodeVersion string           `json:"code_version"`
+	Timings     []StepTiming     `json:"timings"`
+	Files       []string         `json:"files"`
+}
+
+// Time adds how long the step took, since it started, to the manifest's timings
+func (m *Manifest) Time(season, step string, started time.Time) {
+	m.Timings = append(m.Timings, StepTiming{
+		Season:  season,
+		Step:    step,
+		Seconds: time.Since(started).Seconds(),
+	})
+}
+
+// Finish records when the run finished, and how long it took
+func (m *Manifest) Finish() {
+	m.Finished = time.Now()
+	m.Seconds = m.Finished.Sub(m.Started).Seconds()
+}
+
+// NewRunDir creates an empty directory for a run started at the time, within the runs directory, and returns
+// the run's ID along with the directory's path. Runs started in the same second are given a numbered suffix.
+func NewRunDir(runsDir string, started time.Time) (string, string, error) {
+	if err := os.MkdirAll(runsDir, 0755); err != nil {
+		return "", "", errors.Wrap(err)
+	}
+
+	id := started.Format(runIDFormat)
+	for suffix := 2; ; suffix++ {
+		runDir := filepath.Join(runsDir, id)
+		err := os.Mkdir(runDir, 0755)
+		if err == nil {
+			return id, runDir, nil
+		}
+		if !os.IsExist(err) {
+			return "", "", errors.Wrap(err)
+		}
+		id = fmt.Sprintf("%v-%v", started.Format(runIDFormat), suffix)
+	}
+}
+
+// WriteManifest writes the manifest into the run directory
+func WriteManifest(runDir string, manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(runDir, ManifestFile), append(data, '\n'), 0644); err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+// ReadManifest reads the manifest of the run directory
+func ReadManifest(runDir string) (Manifest, error) {
+	file, err := os.Open(filepath.Join(runDir, ManifestFile))
+	if err != nil {
+		return Manifest{}, errors.Wrap(err)
+	}
+	defer file.Close()
+
+	manifest := Manifest{}
+	if err := json.NewDecoder(file).Decode(&manifest); err != nil {
+		return Manifest{}, errors.Wrap(err)
+	}
+	return manifest, nil
+}
+
+// ListRuns returns the manifest of every run in the runs directory, from oldest to newest. Directories without
+// a manifest, such as runs which never finished, are left out.
+func ListRuns(runsDir string) ([]Manifest, error) {
+	entries, err := ioutil.ReadDir(runsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err)
+	}
+
+	manifests := make([]Manifest, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		runDir := filepath.Join(runsDir, entry.Name())
+		if _, err := os.Stat(filepath.Join(runDir, ManifestFile)); os.IsNotExist(err) {
+			continue
+		}
+		manifest, err := ReadManifest(runDir)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	sort.SliceStable(manifests, func(i, j int) bool {
+		return manifests[i].Started.Before(manifests[j].Started)
+	})
+	return manifests, nil
+}
+
+// DataFingerprint returns a hash of the player data of every season, which is the same however the data is
+// stored, so runs made with different data can be told apart
+func (r *Resolver) DataFingerprint(seasons []string) (string, error) {
+	hash := sha256.New()
+	for _, season := range seasons {
+		rows := make([]string, 0)
+		for _, position := range database.Positions {
+			players, err := r.Database.GetPositionPlayers(season, position)
+			if err != nil {
+				return "", errors.Wrap(err)
+			}
+			for _, player := range players {
+				rows = append(rows, fmt.Sprintf("%+v", player))
+			}
+		}
+		gwData, err := r.Database.GetAllPlayerData(season)
+		if err != nil {
+			return "", errors.Wrap(err)
+		}
+		for _, gw := range gwData {
+			rows = append(rows, fmt.Sprintf("%+v", gw))
+		}
+
+		// The rows are sorted, so the order the data is stored in doesn't change the fingerprint
+		sort.Strings(rows)
+		_, _ = fmt.Fprintln(hash, season)
+		for _, row := range rows {
+			_, _ = fmt.Fprintln(hash, row)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// unknownVersion is the code version of a run made outside a git repository
+const unknownVersion = "unknown"
+
+// BuildVersion is the git commit the binary was built from, set when building with
+// '-ldflags "-X fpl-strategy-tester/internal.BuildVersion=$(git rev-parse HEAD)"'
+var BuildVersion string
+
+// CodeVersion returns the git commit the code was built from, marked as dirty when it had uncommitted changes.
+// It is taken from BuildVersion when set, otherwise from the git repository the run is made in.
+func CodeVersion() string {
+	if BuildVersion != "" {
+		return BuildVersion
+	}
+
+	revision, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return unknownVersion
+	}
+	version := strings.TrimSpace(string(revision))
+	if status, err := exec.Command("git", "status", "--porcelain", "--untracked-files=no").Output(); err == nil &&
+		len(strings.TrimSpace(string(status))) > 0 {
+		version += "-dirty"
+	}
+	return version
+}
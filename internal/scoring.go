@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"fpl-strategy-tester/internal/database"
+
+	"github.com/icelolly/go-errors"
+)
+
+/*	SCORING:
+	This file of code manages the scoring of a squad, game week by game week.
+	As in FPL, only the players in the starting XI score points for the team, any starters
+	who didn't play are automatically substituted from the bench, and the captain's points
+	are multiplied.
+*/
+
+// CalculateTeamPoints takes the team of players and returns a total of their end-of-season points.
+// The starting XI is picked by price, and only the starters' points are counted, with the captain picked
+// each game week by the captaincy policy.
+func (r *Resolver) CalculateTeamPoints(squad Squad, policy CaptaincyPolicy) (int, error) {
+	season := squad.Season()
+	points, ok := r.Points[season]
+	if !ok {
+		return 0, errors.New("No points loaded for season: " + season)
+	}
+
+	rules := r.ResolveRules(season)
+	lineup := PickLineup(squad, rules, RankByPrice)
+
+	teamPoints := 0
+	for gw := 1; gw <= points.Gameweeks(); gw++ {
+		captains := PickCaptains(lineup, gw, policy, rules.CaptainMultiplier)
+		gwPoints, err := r.CalculateGameweekPoints(lineup, rules, gw, captains)
+		if err != nil {
+			return 0, errors.Wrap(err)
+		}
+		teamPoints += gwPoints
+	}
+
+	return teamPoints, nil
+}
+
+// CalculateGameweekPoints returns the points scored by the lineup's starting XI in the game week,
+// after making any automatic substitutions. The captain's points are multiplied, or the vice-captain's
+// if the captain didn't play.
+func (r *Resolver) CalculateGameweekPoints(lineup Lineup, rules Rules, gw int, captains Captains) (int, error) {
+	lineup = AutoSubstitute(lineup, rules, func(player database.PlayerInfo) bool {
+		return r.minutesPlayed(player, gw) > 0
+	})
+
+	teamPoints := 0
+	for _, player := range lineup.Starters {
+		points, ok := r.Points[player.Season]
+		if !ok {
+			return 0, errors.New("No points loaded for season: " + player.Season)
+		}
+
+		playerPoints, err := points.Points(player.ID, gw)
+		if err != nil {
+			return 0, errors.Wrap(err)
+		}
+		teamPoints += playerPoints
+	}
+
+	// Add the extra points of whichever captain played
+	captain := captains.Captain
+	if r.minutesPlayed(captain, gw) == 0 {
+		captain = captains.ViceCaptain
+	}
+	if r.minutesPlayed(captain, gw) > 0 && captains.Multiplier > 1 {
+		teamPoints += r.pointsScored(captain, gw, gw) * (captains.Multiplier - 1)
+	}
+
+	return teamPoints, nil
+}
+
+// minutesPlayed returns the minutes the player played in the game week, treating any missing data as
+// not having played
+func (r *Resolver) minutesPlayed(player database.PlayerInfo, gw int) int {
+	points, ok := r.Points[player.Season]
+	if !ok {
+		return 0
+	}
+	minutes, err := points.Minutes(player.ID, gw)
+	if err != nil {
+		return 0
+	}
+	return minutes
+}
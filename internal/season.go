@@ -0,0 +1,275 @@
+package internal
+
+import (
+	"fmt"
+	"fpl-strategy-tester/internal/database"
+	"strconv"
+	"strings"
+
+	"github.com/icelolly/go-errors"
+)
+
+/*	SEASON:
+	This file of code steps a manager's team through a season, game week by game week.
+	Before each game week's deadline the manager makes their transfers, picks their lineup
+	and captain, and decides whether to play a chip. The week is then scored, and the
+	team carries its squad, bank, free transfers and chips into the next game week.
+*/
+
+// Transfer swaps a player in the squad for a new player
+type Transfer struct {
+	Out database.PlayerInfo
+	In  database.PlayerInfo
+}
+
+// Decisions are the choices a manager makes before a game week's deadline.
+// The lineup is picked from the squad after the transfers have been made, and the chip is left empty
+// when none is played.
+type Decisions struct {
+	Transfers   []Transfer
+	Lineup      Lineup
+	Captain     database.PlayerInfo
+	ViceCaptain database.PlayerInfo
+	Chip        Chip
+}
+
+// Manager makes the decisions for a team each game week
+type Manager interface {
+	Name() string
+	Decide(state SeasonState) (Decisions, error)
+}
+
+// SeasonState is a manager's team at the start of a game week. Prices are in units of £0.1M, and the price
+// of each player in the squad is the price they were bought for.
+type SeasonState struct {
+	Rules         Rules
+	GW            int
+	Squad         Squad
+	Bank          int
+	TeamValue     int
+	FreeTransfers int
+	Chips         map[Chip]int
+	TotalPoints   int
+}
+
+// GameweekResult is the outcome of a single game week of a manager's season
+type GameweekResult struct {
+	GW          int
+	Points      int
+	TotalPoints int
+	Transfers   int
+	HitPoints   int
+	Bank        int
+	TeamValue   int
+	Chip        Chip
+}
+
+// SimulateSeason plays the squad through every game week of its season, with the manager making the decisions.
+// It returns the result of each game week in order. Game weeks without any fixtures, such as gaps in the
+// season's numbering, aren't played and give no free transfers.
+func (r *Resolver) SimulateSeason(squad Squad, manager Manager) ([]GameweekResult, error) {
+	season := squad.Season()
+	if _, ok := r.Points[season]; !ok {
+		return nil, errors.New("No points loaded for season: " + season)
+	}
+	calendar, ok := r.Calendars[season]
+	if !ok {
+		return nil, errors.New("No fixture calendar loaded for season: " + season)
+	}
+
+	rules := r.ResolveRules(season)
+
+	// The squad is picked before game week 1, so the first free transfers are given for game week 2
+	state := SeasonState{
+		Rules: rules,
+		Squad: squad,
+		Bank:  rules.Budget - squad.Price(),
+		Chips: make(map[Chip]int),
+	}
+	for chip, count := range rules.Chips {
+		state.Chips[chip] = count
+	}
+
+	gameweeks := calendar.PlayedGameweeks()
+	results := make([]GameweekResult, 0, len(gameweeks))
+	chipsReset := false
+	for _, gw := range gameweeks {
+		state.GW = gw
+		state.TeamValue = r.teamValue(state.Squad, gw)
+
+		// Give the chips again for the second half of the season, losing any left from the first half
+		if rules.ChipsResetGameweek > 0 && gw >= rules.ChipsResetGameweek && !chipsReset {
+			chipsReset = true
+			state.Chips = make(map[Chip]int)
+			for chip, count := range rules.Chips {
+				state.Chips[chip] = count
+			}
+		}
+
+		decisions, err := manager.Decide(state)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		// Play the chip, if the manager still has it
+		if decisions.Chip != "" {
+			if state.Chips[decisions.Chip] < 1 {
+				return nil, errors.New(fmt.Sprintf("GW%v: chip %v is not available", gw, decisions.Chip))
+			}
+			state.Chips[decisions.Chip]--
+		}
+
+		// Make the transfers, remembering the squad in case a Free Hit needs to be undone
+		preDeadline := state
+		hitPoints, err := r.makeTransfers(&state, decisions.Transfers, decisions.Chip)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+
+		// Score the game week. With the Bench Boost every player in the squad scores.
+		lineup := decisions.Lineup
+		if decisions.Chip == BenchBoost {
+			lineup = Lineup{Starters: state.Squad.Players()}
+		} else if err := validateLineup(lineup, state.Squad, rules); err != nil {
+			return nil, errors.New(fmt.Sprintf("GW%v: %v", gw, err))
+		}
+		captains := Captains{
+			Captain:     decisions.Captain,
+			ViceCaptain: decisions.ViceCaptain,
+			Multiplier:  rules.CaptainMultiplier,
+		}
+		if decisions.Chip == TripleCaptain {
+			captains.Multiplier = rules.TripleCaptainMultiplier
+		}
+		gwPoints, err := r.CalculateGameweekPoints(lineup, rules, gw, captains)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		gwPoints -= hitPoints
+		state.TotalPoints += gwPoints
+
+		results = append(results, GameweekResult{
+			GW:          gw,
+			Points:      gwPoints,
+			TotalPoints: state.TotalPoints,
+			Transfers:   len(decisions.Transfers),
+			HitPoints:   hitPoints,
+			Bank:        state.Bank,
+			TeamValue:   r.teamValue(state.Squad, gw),
+			Chip:        decisions.Chip,
+		})
+
+		// The squad returns to how it was before a Free Hit
+		if decisions.Chip == FreeHit {
+			state.Squad = preDeadline.Squad
+			state.Bank = preDeadline.Bank
+		}
+
+		// Unused free transfers roll over to the next game week, up to the limit
+		state.FreeTransfers += rules.Transfers.FreeTransfers
+		if state.FreeTransfers > rules.Transfers.MaxFreeTransfers {
+			state.FreeTransfers = rules.Transfers.MaxFreeTransfers
+		}
+	}
+
+	return results, nil
+}
+
+// makeTransfers swaps the players in the squad, buying players at their current price and selling them at their
+// selling price. It uses up the free transfers, and returns the points deducted for any transfers above them.
+// No free transfers are used, and no points are deducted, when a Wildcard or Free Hit has been played.
+func (r *Resolver) makeTransfers(state *SeasonState, transfers []Transfer, chip Chip) (int, error) {
+	if len(transfers) == 0 {
+		return 0, nil
+	}
+
+	players := state.Squad.Players()
+	bank := state.Bank
+	for _, transfer := range transfers {
+		key := -1
+		for i, player := range players {
+			if player.ID == transfer.Out.ID {
+				key = i
+			}
+		}
+		if key == -1 {
+			return 0, errors.New("Transferred out player is not in the squad: " + strconv.Itoa(int(transfer.Out.ID)))
+		}
+
+		// The player bought keeps the price they were bought for
+		in := transfer.In
+		in.Price = r.playerValue(in, state.GW)
+		bank += r.SellingPrice(players[key], state.GW, state.Rules) - in.Price
+		players[key] = in
+	}
+
+	squad := NewSquad(players)
+	if violations := ValidateComposition(squad, state.Rules); len(violations) > 0 {
+		return 0, errors.New("Transfers break the squad rules: " + strings.Join(violations, ", "))
+	}
+	if bank < 0 {
+		return 0, errors.New("Transfers cost more than the bank: " + strconv.Itoa(bank))
+	}
+	state.Squad = squad
+	state.Bank = bank
+
+	if chip == Wildcard || chip == FreeHit {
+		return 0, nil
+	}
+	paidTransfers := len(transfers) - state.FreeTransfers
+	if paidTransfers < 0 {
+		paidTransfers = 0
+	}
+	state.FreeTransfers -= len(transfers) - paidTransfers
+	return paidTransfers * state.Rules.Transfers.HitCost, nil
+}
+
+// validateLineup checks the lineup is made of players from the squad, and has a valid starting XI
+func validateLineup(lineup Lineup, squad Squad, rules Rules) error {
+	inSquad := make(map[database.PlayerID]bool)
+	for _, player := range squad.Players() {
+		inSquad[player.ID] = true
+	}
+	for _, player := range append(append([]database.PlayerInfo{}, lineup.Starters...), lineup.Bench...) {
+		if !inSquad[player.ID] {
+			return errors.New("Lineup player is not in the squad: " + strconv.Itoa(int(player.ID)))
+		}
+	}
+	if len(lineup.Starters) != rules.StartingXI || !ValidFormation(lineup.Starters, rules) {
+		return errors.New("Starting XI does not fit the formation limits")
+	}
+	return nil
+}
+
+// SellingPrice returns what the player would sell for in the game week. As in FPL, only a share of any rise
+// since the player was bought is kept, rounded down to the nearest £0.1M, while any fall is lost in full.
+func (r *Resolver) SellingPrice(player database.PlayerInfo, gw int, rules Rules) int {
+	value := r.playerValue(player, gw)
+	if value <= player.Price {
+		return value
+	}
+	return player.Price + int(float64(value-player.Price)*rules.Transfers.SellingProfitShare)
+}
+
+// teamValue returns the combined price of the squad's players in the game week
+func (r *Resolver) teamValue(squad Squad, gw int) int {
+	value := 0
+	for _, player := range squad.Players() {
+		value += r.playerValue(player, gw)
+	}
+	return value
+}
+
+// playerValue returns the player's price in the game week, falling back on their pre-season price when there
+// is no game week data for them
+func (r *Resolver) playerValue(player database.PlayerInfo, gw int) int {
+	points, ok := r.Points[player.Season]
+	if !ok {
+		return player.Price
+	}
+	value, err := points.Value(player.ID, gw)
+	if err != nil || value == 0 {
+		return player.Price
+	}
+	return value
+}
@@ -0,0 +1,179 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/icelolly/go-errors"
+)
+
+/*	SEASON TRACE:
+	This file of code plays every simulated team through its season with each manager,
+	and records the points, transfers, bank and team value of every game week.
+*/
+
+func init() {
+	RegisterStrategy(seasonTraceName, func(r *Resolver) Strategy { return NewSeasonTraceStrategy(r) })
+}
+
+// Name used to pick the strategy from the command line
+const seasonTraceName = "season-trace"
+
+// Results file for the season trace, along with its column headers
+const SeasonTraceFile = "season_trace.csv"
+const SeasonTraceHeader = "Season, " +
+	"Manager, " +
+	"Team, " +
+	"GW, " +
+	"Points, " +
+	"Total Points, " +
+	"Transfers, " +
+	"Hit Points, " +
+	"Bank, " +
+	"Team Value, " +
+	"Chip\n"
+
+// Results file summarising the season of each manager, along with its column headers
+const SeasonSummaryFile = "season_summary.csv"
+const SeasonSummaryHeader = "Season, " +
+	"Manager, " +
+	"Average Points, " +
+	"Average Transfers, " +
+	"Average Hit Points, " +
+	"5th Percentile, " +
+	"25th Percentile, " +
+	"50th Percentile, " +
+	"75th Percentile, " +
+	"95th Percentile\n"
+
+// SeasonTraceStrategy simulates the season of each team with every manager. Every manager starts from the same
+// teams, so their results can be compared.
+type SeasonTraceStrategy struct {
+	resolver *Resolver
+	managers []Manager
+}
+
+// NewSeasonTraceStrategy creates the strategy with the managers who keep their starting squad, and who make
+// transfers with and without taking hits
+func NewSeasonTraceStrategy(r *Resolver) *SeasonTraceStrategy {
+	captaincy := r.MostExpensiveCaptain()
+	return &SeasonTraceStrategy{
+		resolver: r,
+		managers: []Manager{
+			SetAndForgetManager{Captaincy: captaincy},
+			NewTransferManager(r, captaincy, false),
+			NewTransferManager(r, captaincy, true),
+		},
+	}
+}
+
+// Name returns the name used to pick the strategy from the command line
+func (s *SeasonTraceStrategy) Name() string {
+	return seasonTraceName
+}
+
+// Description returns a summary of what the strategy tests
+func (s *SeasonTraceStrategy) Description() string {
+	return "Game week by game week points of the same teams under each transfer policy"
+}
+
+// Parameters returns the managers compared, their captaincy policy, and the number of game weeks used to
+// measure form
+func (s *SeasonTraceStrategy) Parameters() map[string]string {
+	return map[string]string{
+		"managers":       managerNames(s.managers),
+		"captaincy":      s.resolver.MostExpensiveCaptain().Name,
+		"form_gameweeks": strconv.Itoa(formGameweeks),
+	}
+}
+
+// Tables returns the trace and summary results files of the strategy
+func (s *SeasonTraceStrategy) Tables() []ResultsTable {
+	return []ResultsTable{
+		{File: SeasonTraceFile, Header: SeasonTraceHeader},
+		{File: SeasonSummaryFile, Header: SeasonSummaryHeader, Comparable: true},
+	}
+}
+
+// Evaluate simulates the team's season with each manager, in the same order as the managers
+func (s *SeasonTraceStrategy) Evaluate(team Squad) (interface{}, error) {
+	traces := make([][]GameweekResult, len(s.managers))
+	for key, manager := range s.managers {
+		results, err := s.resolver.SimulateSeason(team, manager)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		traces[key] = results
+	}
+	return traces, nil
+}
+
+// Aggregate returns a trace row for each manager, team and game week, and a summary row for each manager,
+// labelled with the season
+func (s *SeasonTraceStrategy) Aggregate(season string, results []interface{}) ([][]string, error) {
+	rows := make([]string, 0)
+	summary := make([]string, 0, len(s.managers))
+	for key, manager := range s.managers {
+		totals := make([]int, 0)
+		transfers, hitPoints := 0, 0
+		for teamNumber, result := range results {
+			if result == nil {
+				continue
+			}
+			trace := result.([][]GameweekResult)[key]
+			for _, gw := range trace {
+				rows = append(rows, fmt.Sprintf("%v, %v, %v, %v, %v, %v, %v, %v, %v, %v, %v",
+					season, manager.Name(), teamNumber+1, gw.GW, gw.Points, gw.TotalPoints,
+					gw.Transfers, gw.HitPoints, gw.Bank, gw.TeamValue, gw.Chip,
+				))
+				transfers += gw.Transfers
+				hitPoints += gw.HitPoints
+			}
+			if len(trace) > 0 {
+				totals = append(totals, trace[len(trace)-1].TotalPoints)
+			}
+		}
+		summary = append(summary, summariseSeasons(season, manager.Name(), totals, transfers, hitPoints))
+	}
+
+	return [][]string{rows, summary}, nil
+}
+
+// Samples returns the total points of the teams' seasons with each manager
+func (s *SeasonTraceStrategy) Samples(season string, results []interface{}) []Sample {
+	samples := make([]Sample, len(s.managers))
+	for key, manager := range s.managers {
+		teams, totals := make([]int, 0), make([]int, 0)
+		for teamNumber, result := range results {
+			if result == nil {
+				continue
+			}
+			if trace := result.([][]GameweekResult)[key]; len(trace) > 0 {
+				teams = append(teams, teamNumber)
+				totals = append(totals, trace[len(trace)-1].TotalPoints)
+			}
+		}
+		samples[key] = Sample{File: SeasonSummaryFile, Season: season, Group: manager.Name(), Teams: teams, Values: totals}
+	}
+	return samples
+}
+
+// summariseSeasons returns the summary row of a manager's seasons, with the average and percentiles of their
+// total points
+func summariseSeasons(season, manager string, totals []int, transfers, hitPoints int) string {
+	if len(totals) == 0 {
+		return fmt.Sprintf("%v, %v, 0, 0, 0, 0, 0, 0, 0, 0", season, manager)
+	}
+
+	pointsSum := 0
+	for _, total := range totals {
+		pointsSum += total
+	}
+	teams := float64(len(totals))
+
+	percentile := findPercentiles(totals)
+	strData := strings.Trim(strings.Replace(fmt.Sprint(percentile), " ", ", ", -1), "[]")
+	return fmt.Sprintf("%v, %v, %.2f, %.2f, %.2f, %v", season, manager,
+		float64(pointsSum)/teams, float64(transfers)/teams, float64(hitPoints)/teams, strData)
+}
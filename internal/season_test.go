@@ -0,0 +1,204 @@
+package internal
+
+import (
+	"fpl-strategy-tester/internal/database"
+	"testing"
+)
+
+const testSeason = "2019-20"
+
+// testPlayers returns a squad of fifteen players spread across six clubs, and a spare midfielder who isn't in it.
+// The squad costs 850, leaving 150 in the bank.
+func testPlayers() ([]database.PlayerInfo, database.PlayerInfo) {
+	squad := make([]database.PlayerInfo, 0, 15)
+	for id := 1; id <= 15; id++ {
+		player := database.PlayerInfo{ID: database.PlayerID(id), Team: (id-1)%6 + 1, Season: testSeason}
+		switch {
+		case id <= 2:
+			player.Position, player.Price = database.Goalkeeper, 45
+		case id <= 7:
+			player.Position, player.Price = database.Defender, 50
+		case id <= 12:
+			player.Position, player.Price = database.Midfielder, 60
+		default:
+			player.Position, player.Price = database.Forward, 70
+		}
+		squad = append(squad, player)
+	}
+	spare := database.PlayerInfo{ID: 16, Position: database.Midfielder, Price: 80, Team: 4, Season: testSeason}
+	return squad, spare
+}
+
+// testResolver loads a season where every player scores 2 points in each of game weeks 1, 2 and 4, with game
+// week 3 a gap in the numbering, and every player's value stays at their price
+func testResolver(t *testing.T) *Resolver {
+	squad, spare := testPlayers()
+	players := append(squad, spare)
+	opponents := map[int]int{1: 2, 2: 1, 3: 4, 4: 3, 5: 6, 6: 5}
+
+	gwData := make([]database.PlayerGWInfo, 0)
+	for _, gw := range []int{1, 2, 4} {
+		for _, player := range players {
+			gwData = append(gwData, database.PlayerGWInfo{
+				Element:      player.ID,
+				GW:           gw,
+				Fixture:      gw*10 + (player.Team+1)/2,
+				OpponentTeam: opponents[player.Team],
+				WasHome:      map[bool]string{true: "True", false: "False"}[player.Team%2 == 1],
+				TotalPoints:  2,
+				Minutes:      90,
+				Value:        player.Price,
+				Season:       testSeason,
+			})
+		}
+	}
+
+	r := NewResolver()
+	r.Database = database.NewMemoryRepository(players, gwData, nil)
+	if _, err := r.ResolvePoints(testSeason); err != nil {
+		t.Fatalf("ResolvePoints(%v) returned an error: %v", testSeason, err)
+	}
+	if _, err := r.ResolveCalendar(testSeason); err != nil {
+		t.Fatalf("ResolveCalendar(%v) returned an error: %v", testSeason, err)
+	}
+	return r
+}
+
+// scriptedManager makes the transfers and plays the chips it is given for each game week, picking the lineup by
+// price and captaining the most expensive starter
+type scriptedManager struct {
+	resolver  *Resolver
+	transfers map[int][]Transfer
+	chips     map[int]Chip
+}
+
+func (m scriptedManager) Name() string {
+	return "Scripted"
+}
+
+func (m scriptedManager) Decide(state SeasonState) (Decisions, error) {
+	squad := applyTransfers(state.Squad, m.transfers[state.GW])
+	lineup := PickLineup(squad, state.Rules, RankByPrice)
+	captains := PickCaptains(lineup, state.GW, m.resolver.MostExpensiveCaptain(), state.Rules.CaptainMultiplier)
+	return Decisions{
+		Transfers:   m.transfers[state.GW],
+		Lineup:      lineup,
+		Captain:     captains.Captain,
+		ViceCaptain: captains.ViceCaptain,
+		Chip:        m.chips[state.GW],
+	}, nil
+}
+
+func TestSimulateSeason(t *testing.T) {
+	squad, spare := testPlayers()
+	midfielder := squad[7]
+
+	// Each chip can be played once in each half of a season whose chips are given again from game week 4
+	halves := SeasonRules(testSeason)
+	halves.Chips = map[Chip]int{BenchBoost: 1}
+	halves.ChipsResetGameweek = 4
+
+	// Nothing is left in the bank, so no more expensive player can be afforded
+	spent := SeasonRules(testSeason)
+	spent.Budget = squad[0].Price*2 + squad[2].Price*5 + squad[7].Price*5 + squad[12].Price*3
+
+	cases := []struct {
+		name      string
+		rules     *Rules
+		transfers map[int][]Transfer
+		chips     map[int]Chip
+		points    []int
+		hitPoints []int
+		bank      []int
+		errors    bool
+	}{
+		// Eleven starters scoring 2 points, with the captain's doubled
+		{name: "no decisions", points: []int{24, 24, 24}, hitPoints: []int{0, 0, 0}, bank: []int{150, 150, 150}},
+		{name: "bench boost", chips: map[int]Chip{2: BenchBoost},
+			points: []int{24, 32, 24}, hitPoints: []int{0, 0, 0}, bank: []int{150, 150, 150}},
+		{name: "triple captain", chips: map[int]Chip{4: TripleCaptain},
+			points: []int{24, 24, 26}, hitPoints: []int{0, 0, 0}, bank: []int{150, 150, 150}},
+		{name: "chip played twice", chips: map[int]Chip{1: BenchBoost, 2: BenchBoost}, errors: true},
+		{name: "chip played in each half", rules: &halves, chips: map[int]Chip{1: BenchBoost, 4: BenchBoost},
+			points: []int{32, 24, 32}, hitPoints: []int{0, 0, 0}, bank: []int{150, 150, 150}},
+		{name: "chip played twice in a half", rules: &halves, chips: map[int]Chip{1: BenchBoost, 2: BenchBoost},
+			errors: true},
+		// No free transfers are given before game week 1, so its transfer costs a hit, while game week 2 has one
+		{name: "transfer with a hit", transfers: map[int][]Transfer{1: {{Out: midfielder, In: spare}}},
+			points: []int{20, 24, 24}, hitPoints: []int{4, 0, 0}, bank: []int{130, 130, 130}},
+		{name: "free transfer", transfers: map[int][]Transfer{2: {{Out: midfielder, In: spare}}},
+			points: []int{24, 24, 24}, hitPoints: []int{0, 0, 0}, bank: []int{150, 130, 130}},
+		{name: "wildcard transfer", transfers: map[int][]Transfer{1: {{Out: midfielder, In: spare}}},
+			chips: map[int]Chip{1: Wildcard}, points: []int{24, 24, 24}, hitPoints: []int{0, 0, 0},
+			bank: []int{130, 130, 130}},
+		// The squad and bank return to how they were after the Free Hit's game week
+		{name: "free hit transfer", transfers: map[int][]Transfer{1: {{Out: midfielder, In: spare}}},
+			chips: map[int]Chip{1: FreeHit}, points: []int{24, 24, 24}, hitPoints: []int{0, 0, 0},
+			bank: []int{130, 150, 150}},
+		{name: "transfer out of a missing player", transfers: map[int][]Transfer{1: {{Out: spare, In: midfielder}}},
+			errors: true},
+		{name: "transfer over the bank", rules: &spent, transfers: map[int][]Transfer{1: {{Out: midfielder, In: spare}}},
+			errors: true},
+	}
+	for _, c := range cases {
+		r := testResolver(t)
+		if c.rules != nil {
+			r.Rules = map[string]Rules{testSeason: *c.rules}
+		}
+
+		manager := scriptedManager{resolver: r, transfers: c.transfers, chips: c.chips}
+		results, err := r.SimulateSeason(NewSquad(squad), manager)
+		if c.errors {
+			if err == nil {
+				t.Errorf("SimulateSeason(%v) returned no error", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("SimulateSeason(%v) returned an error: %v", c.name, err)
+		}
+
+		// Game week 3 has no fixtures, so isn't played
+		if len(results) != 3 {
+			t.Fatalf("SimulateSeason(%v) returned %v game weeks, want 3", c.name, len(results))
+		}
+		total := 0
+		for key, gw := range []int{1, 2, 4} {
+			result := results[key]
+			total += c.points[key]
+			if result.GW != gw || result.Points != c.points[key] || result.TotalPoints != total ||
+				result.HitPoints != c.hitPoints[key] || result.Bank != c.bank[key] || result.Chip != c.chips[gw] {
+				t.Errorf("SimulateSeason(%v) GW%v = %+v, want %v points, %v total, %v hit points and %v in the bank",
+					c.name, gw, result, c.points[key], total, c.hitPoints[key], c.bank[key])
+			}
+		}
+	}
+}
+
+func TestSellingPrice(t *testing.T) {
+	r := testResolver(t)
+	rules := SeasonRules(testSeason)
+	squad, _ := testPlayers()
+
+	// The midfielder's value is 60 in every game week
+	cases := []struct {
+		player database.PlayerID
+		bought int
+		want   int
+	}{
+		{8, 60, 60},
+		{8, 56, 58},
+		{8, 57, 58},
+		{8, 63, 60},
+		// A player without any game week data sells for what they were bought for
+		{99, 55, 55},
+	}
+	for _, c := range cases {
+		player := squad[7]
+		player.ID = c.player
+		player.Price = c.bought
+		if got := r.SellingPrice(player, 2, rules); got != c.want {
+			t.Errorf("SellingPrice(%v bought at %v) = %v, want %v", c.player, c.bought, got, c.want)
+		}
+	}
+}
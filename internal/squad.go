@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"fpl-strategy-tester/internal/database"
+)
+
+// Squad is a team of FPL players, held by their position rather than their place in a list
+type Squad struct {
+	players map[database.Position][]database.PlayerInfo
+}
+
+// NewSquad groups the players into a squad by their position
+func NewSquad(players []database.PlayerInfo) Squad {
+	squad := Squad{players: make(map[database.Position][]database.PlayerInfo)}
+	for _, player := range players {
+		squad.players[player.Position] = append(squad.players[player.Position], player)
+	}
+	return squad
+}
+
+// Season returns the season the squad's players were picked from
+func (s Squad) Season() string {
+	for _, position := range database.Positions {
+		if len(s.players[position]) > 0 {
+			return s.players[position][0].Season
+		}
+	}
+	return ""
+}
+
+// Position returns the players in the squad who play in the position. The slice is shared with the squad, so
+// must not be changed.
+func (s Squad) Position(position database.Position) []database.PlayerInfo {
+	return s.players[position]
+}
+
+// Players returns every player in the squad, ordered by position
+func (s Squad) Players() []database.PlayerInfo {
+	players := make([]database.PlayerInfo, 0, s.Size())
+	for _, position := range database.Positions {
+		players = append(players, s.players[position]...)
+	}
+	return players
+}
+
+// Size returns the number of players in the squad
+func (s Squad) Size() int {
+	size := 0
+	for _, players := range s.players {
+		size += len(players)
+	}
+	return size
+}
+
+// Complete checks the squad has the right number of players in every position
+func (s Squad) Complete(quotas map[database.Position]int) bool {
+	for _, position := range database.Positions {
+		if len(s.players[position]) != quotas[position] {
+			return false
+		}
+	}
+	return true
+}
+
+// Price returns the combined worth of all players in the squad
+func (s Squad) Price() int {
+	return CalculatePrice(s.Players())
+}
@@ -0,0 +1,181 @@
+package internal
+
+import (
+	"math"
+	"sort"
+)
+
+/*	STATS:
+	This file of code tests whether the difference between two sets of results is
+	bigger than could be put down to chance. Results of the same teams are paired
+	team by team, and otherwise tested with Welch's t-test, which doesn't assume
+	the two sets have the same spread. Testing many rows at once is corrected for
+	with the Holm method.
+*/
+
+// WelchTTest returns the t statistic of the difference in the means of the two samples, and the two-sided
+// p-value: the chance of a difference at least as big if the samples had the same mean. Samples with fewer than
+// two values can't be tested, and give a p-value of one.
+func WelchTTest(a, b []int) (float64, float64) {
+	if len(a) < 2 || len(b) < 2 {
+		return 0, 1
+	}
+
+	meanA, varA := meanVariance(a)
+	meanB, varB := meanVariance(b)
+	errA, errB := varA/float64(len(a)), varB/float64(len(b))
+
+	// Without any spread in either sample, the means are either exactly the same or certainly different
+	if errA+errB == 0 {
+		if meanA == meanB {
+			return 0, 1
+		}
+		return math.Copysign(math.Inf(1), meanB-meanA), 0
+	}
+
+	t := (meanB - meanA) / math.Sqrt(errA+errB)
+	df := (errA + errB) * (errA + errB) /
+		(errA*errA/float64(len(a)-1) + errB*errB/float64(len(b)-1))
+	return t, studentTPValue(t, df)
+}
+
+// PairedTTest returns the t statistic of the mean difference between paired values of the two samples, such as
+// the points of the same team in two runs, and its two-sided p-value. The samples must be the same length, and
+// fewer than two pairs give a p-value of one.
+func PairedTTest(a, b []int) (float64, float64) {
+	if len(a) != len(b) || len(a) < 2 {
+		return 0, 1
+	}
+
+	diffs := make([]int, len(a))
+	for key := range a {
+		diffs[key] = b[key] - a[key]
+	}
+	mean, variance := meanVariance(diffs)
+
+	// Without any spread in the differences, the teams either all changed by the same amount or not at all
+	if variance == 0 {
+		if mean == 0 {
+			return 0, 1
+		}
+		return math.Copysign(math.Inf(1), mean), 0
+	}
+
+	t := mean / math.Sqrt(variance/float64(len(diffs)))
+	return t, studentTPValue(t, float64(len(diffs)-1))
+}
+
+// studentTPValue returns the two-sided p-value of the t statistic under Student's t distribution with df degrees
+// of freedom, from the regularized incomplete beta function
+func studentTPValue(t, df float64) float64 {
+	return regularizedBeta(df/(df+t*t), df/2, 0.5)
+}
+
+// HolmCorrection adjusts the p-values of several tests made at once with the Holm method, so that the chance of
+// any test being significant by chance alone stays within the significance level. The adjusted p-values are
+// returned in the same order.
+func HolmCorrection(pValues []float64) []float64 {
+	order := make([]int, len(pValues))
+	for key := range order {
+		order[key] = key
+	}
+	sort.SliceStable(order, func(i, j int) bool { return pValues[order[i]] < pValues[order[j]] })
+
+	// Scale the smallest p-value by the number of tests, the next by one fewer, and so on, never letting an
+	// adjusted p-value fall below that of a smaller one
+	adjusted := make([]float64, len(pValues))
+	largest := 0.0
+	for rank, key := range order {
+		p := math.Min(1, float64(len(pValues)-rank)*pValues[key])
+		largest = math.Max(largest, p)
+		adjusted[key] = largest
+	}
+	return adjusted
+}
+
+// meanVariance returns the mean and the sample variance of the values
+func meanVariance(values []int) (float64, float64) {
+	sum := 0.0
+	for _, value := range values {
+		sum += float64(value)
+	}
+	mean := sum / float64(len(values))
+
+	squares := 0.0
+	for _, value := range values {
+		squares += (float64(value) - mean) * (float64(value) - mean)
+	}
+	return mean, squares / float64(len(values)-1)
+}
+
+// regularizedBeta returns the regularized incomplete beta function I_x(a, b), evaluated as a continued fraction
+func regularizedBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+
+	// The continued fraction converges quickly below the mean of the distribution, so use the symmetry
+	// I_x(a, b) = 1 - I_1-x(b, a) above it
+	if x > (a+1)/(a+b+2) {
+		return 1 - regularizedBeta(1-x, b, a)
+	}
+
+	lgammaA, _ := math.Lgamma(a)
+	lgammaB, _ := math.Lgamma(b)
+	lgammaAB, _ := math.Lgamma(a + b)
+	front := math.Exp(lgammaAB - lgammaA - lgammaB + a*math.Log(x) + b*math.Log(1-x))
+	return front * betaFraction(x, a, b) / a
+}
+
+// betaFraction evaluates the continued fraction of the incomplete beta function, using Lentz's method
+func betaFraction(x, a, b float64) float64 {
+	const maxIterations = 300
+	const epsilon = 1e-14
+	const tiny = 1e-300
+
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	fraction := d
+
+	for m := 1; m <= maxIterations; m++ {
+		m := float64(m)
+
+		// Even step of the fraction
+		numerator := m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m))
+		d = 1 + numerator*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + numerator/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		fraction *= d * c
+
+		// Odd step of the fraction
+		numerator = -(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1))
+		d = 1 + numerator*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + numerator/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		fraction *= delta
+
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return fraction
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRegularizedBeta(t *testing.T) {
+	cases := []struct {
+		x, a, b float64
+		want    float64
+	}{
+		{0.3, 1, 1, 0.3},
+		{0.5, 2, 3, 0.6875},
+		{0.4, 3, 1, 0.064},
+		{0.9, 3, 1, 0.729},
+		{0.2, 1, 4, 1 - math.Pow(0.8, 4)},
+	}
+	for _, c := range cases {
+		if got := regularizedBeta(c.x, c.a, c.b); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("regularizedBeta(%v, %v, %v) = %v, want %v", c.x, c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestStudentTPValue(t *testing.T) {
+	// Critical values of Student's t distribution, from standard tables
+	cases := []struct {
+		t, df float64
+		want  float64
+	}{
+		{1.812461, 10, 0.10},
+		{2.228139, 10, 0.05},
+		{3.169273, 10, 0.01},
+		{2.085963, 20, 0.05},
+		{1.959964, 1e6, 0.05},
+		{0, 10, 1},
+	}
+	for _, c := range cases {
+		if got := studentTPValue(c.t, c.df); math.Abs(got-c.want) > 1e-5 {
+			t.Errorf("studentTPValue(%v, %v) = %v, want %v", c.t, c.df, got, c.want)
+		}
+	}
+}
+
+func TestWelchTTest(t *testing.T) {
+	a := []int{27, 31, 26, 30, 29, 28, 33, 25}
+	b := []int{34, 29, 35, 32, 36, 31, 33, 30, 37}
+	tStat, p := WelchTTest(a, b)
+	if math.Abs(tStat-3.33207) > 1e-4 {
+		t.Errorf("WelchTTest t = %v, want 3.33207", tStat)
+	}
+	if p < 0.004 || p > 0.006 {
+		t.Errorf("WelchTTest p = %v, want about 0.0046", p)
+	}
+
+	if _, p := WelchTTest(a, a); p != 1 {
+		t.Errorf("WelchTTest of identical samples p = %v, want 1", p)
+	}
+	if _, p := WelchTTest([]int{1}, b); p != 1 {
+		t.Errorf("WelchTTest of a single value p = %v, want 1", p)
+	}
+}
+
+func TestPairedTTest(t *testing.T) {
+	// The differences are 1, 2, 2 and 3, with a mean of 2 and a standard deviation of sqrt(2/3)
+	tStat, p := PairedTTest([]int{1, 2, 3, 4}, []int{2, 4, 5, 7})
+	if math.Abs(tStat-4.898979) > 1e-5 {
+		t.Errorf("PairedTTest t = %v, want 4.898979", tStat)
+	}
+	if math.Abs(p-studentTPValue(4.898979485566356, 3)) > 1e-9 || p < 0.01 || p > 0.02 {
+		t.Errorf("PairedTTest p = %v, want about 0.0163", p)
+	}
+
+	if _, p := PairedTTest([]int{1, 2, 3}, []int{1, 2, 3}); p != 1 {
+		t.Errorf("PairedTTest of identical samples p = %v, want 1", p)
+	}
+	if _, p := PairedTTest([]int{1, 2, 3}, []int{2, 3, 4}); p != 0 {
+		t.Errorf("PairedTTest of a constant difference p = %v, want 0", p)
+	}
+}
+
+func TestHolmCorrection(t *testing.T) {
+	got := HolmCorrection([]float64{0.04, 0.01, 0.03, 0.5})
+	want := []float64{0.09, 0.04, 0.09, 0.5}
+	for key := range want {
+		if math.Abs(got[key]-want[key]) > 1e-12 {
+			t.Errorf("HolmCorrection = %v, want %v", got, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/icelolly/go-errors"
+)
+
+/*	STRATEGY:
+	This file of code manages the strategies run against the simulated teams.
+	Each strategy evaluates the teams of the season's pool, then aggregates the results
+	of every team into the rows of its results files. The spreading of the teams across the
+	workers, and the collection of their results, is shared between every strategy.
+*/
+
+// ResultsTable is a results file written by a strategy into the run directory, along with its column headers.
+// Comparable tables have a single row for each season and group, found in their first two columns, so their
+// rows can be lined up with those of another run.
+type ResultsTable struct {
+	File       string
+	Header     string
+	Comparable bool
+}
+
+// Sample is the value of every team behind a row of a comparable results table, such as the points of each team
+// in a team price bucket. The row is the one labelled with the sample's season and group. Teams holds the index
+// in the pool of the team behind each value, so the samples of runs with the same teams can be paired.
+type Sample struct {
+	File   string
+	Season string
+	Group  string
+	Teams  []int
+	Values []int
+}
+
+// Strategy is an FPL strategy, tested against the simulated teams of each season
+type Strategy interface {
+	// Name is the name used to pick the strategy from the command line
+	Name() string
+
+	// Description is a one-line summary of what the strategy tests
+	Description() string
+
+	// Parameters returns the settings the strategy runs with, by name, to be recorded with its results
+	Parameters() map[string]string
+
+	// Tables returns the results files written by the strategy
+	Tables() []ResultsTable
+
+	// Evaluate returns the strategy's result for a single team, or nil if the team should be left out.
+	// It is called for many teams at once, and must not change the team, which is shared with other strategies.
+	Evaluate(team Squad) (interface{}, error)
+
+	// Aggregate takes the result of each team in the season, and returns the rows for each of the strategy's
+	// results files, labelled with the season. The results are in the order of the teams, with a nil result for
+	// any team left out.
+	Aggregate(season string, results []interface{}) ([][]string, error)
+
+	// Samples takes the same results as Aggregate, and returns the value of every team behind each row of the
+	// strategy's comparable results tables
+	Samples(season string, results []interface{}) []Sample
+}
+
+// strategyRegistry holds the constructor of every strategy, by name
+var strategyRegistry = make(map[string]func(r *Resolver) Strategy)
+
+// RegisterStrategy adds the strategy to the registry, so it can be run by name
+func RegisterStrategy(name string, constructor func(r *Resolver) Strategy) {
+	strategyRegistry[name] = constructor
+}
+
+// StrategyNames returns the name of every registered strategy, in alphabetical order
+func StrategyNames() []string {
+	names := make([]string, 0, len(strategyRegistry))
+	for name := range strategyRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewStrategies creates the strategies with the names given, or every registered strategy if no names are given
+func (r *Resolver) NewStrategies(names []string) ([]Strategy, error) {
+	if len(names) == 0 {
+		names = StrategyNames()
+	}
+
+	strategies := make([]Strategy, 0, len(names))
+	for _, name := range names {
+		constructor, ok := strategyRegistry[name]
+		if !ok {
+			return nil, errors.New(fmt.Sprintf(
+				"Unknown strategy %v, expected one of: %v", name, strings.Join(StrategyNames(), ", "),
+			))
+		}
+		strategies = append(strategies, constructor(r))
+	}
+	return strategies, nil
+}
+
+// StrategyResult is the outcome of running a strategy against a season's pool of teams. Rows holds the rows for
+// each of the strategy's results files, labelled with the season, and Samples the values behind them. Any team the
+// strategy failed to evaluate is left out of the results, and counted in Dropped along with its error.
+type StrategyResult struct {
+	Rows    [][]string
+	Samples []Sample
+	Dropped int
+	Errors  []error
+}
+
+// RunStrategy evaluates the strategy against every team in the pool, then aggregates the results
+func (r *Resolver) RunStrategy(ctx context.Context, strategy Strategy, pool *TeamPool) (StrategyResult, error) {
+
+	// The result of each team, in the order of the pool
+	results := make([]interface{}, pool.Size())
+
+	errs := r.ResolveWorkers().Run(ctx, pool.Size(), func(ctx context.Context, teamNumber int) error {
+		team, err := pool.Team(teamNumber)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		result, err := strategy.Evaluate(team)
+		if err != nil {
+			return errors.Wrap(err)
+		}
+		results[teamNumber] = result
+		return nil
+	})
+
+	// Stop if the run has been cancelled, otherwise count the teams left out
+	if ctx.Err() != nil {
+		return StrategyResult{}, errors.Wrap(ctx.Err())
+	}
+
+	rows, err := strategy.Aggregate(pool.Season(), results)
+	if err != nil {
+		return StrategyResult{}, errors.Wrap(err)
+	}
+	return StrategyResult{
+		Rows:    rows,
+		Samples: strategy.Samples(pool.Season(), results),
+		Dropped: len(errs),
+		Errors:  errs,
+	}, nil
+}
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+
+	"github.com/icelolly/go-errors"
+)
+
+// TeamPool is the population of simulated teams for a season, which every strategy is run against.
+// Each team keeps the same index for every strategy, so their results can be lined up team by team.
+type TeamPool struct {
+	season string
+	seed   int64
+	teams  []Squad
+}
+
+// newTeamPool creates a pool from the generated teams, which must not be changed afterwards
+func newTeamPool(season string, seed int64, teams []Squad) *TeamPool {
+	return &TeamPool{season: season, seed: seed, teams: teams}
+}
+
+// Season returns the season the teams were picked from
+func (p *TeamPool) Season() string {
+	return p.season
+}
+
+// Seed returns the seed the teams were generated from, which generates the same teams again
+func (p *TeamPool) Seed() int64 {
+	return p.seed
+}
+
+// Size returns the number of teams in the pool
+func (p *TeamPool) Size() int {
+	return len(p.teams)
+}
+
+// Team returns the team at the index
+func (p *TeamPool) Team(index int) (Squad, error) {
+	if index < 0 || index >= len(p.teams) {
+		return Squad{}, errors.New("Team index out of range: " + strconv.Itoa(index))
+	}
+	return p.teams[index], nil
+}
+
+// TeamRand returns the random number generator used to pick the team at the index. Its numbers depend only on
+// the run's seed, the season and the index, so the team is the same in every run with the same seed.
+func TeamRand(seed int64, season string, index int) *rand.Rand {
+	hash := fnv.New64a()
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, uint64(seed))
+	_, _ = hash.Write(buf)
+	_, _ = hash.Write([]byte(season))
+	binary.LittleEndian.PutUint64(buf, uint64(index))
+	_, _ = hash.Write(buf)
+	return rand.New(rand.NewSource(int64(hash.Sum64())))
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"fmt"
+	"fpl-strategy-tester/internal/database"
+)
+
+// ValidateSquad checks the squad against the FPL squad rules, and returns a description of each rule broken.
+// A legal squad returns no descriptions.
+func ValidateSquad(squad Squad, rules Rules) []string {
+	violations := ValidateComposition(squad, rules)
+
+	// Check the squad is within budget
+	if price := squad.Price(); price > rules.Budget {
+		violations = append(violations, fmt.Sprintf("Squad price %v is above the budget of %v", price, rules.Budget))
+	}
+
+	return violations
+}
+
+// ValidateComposition checks the squad's positions, players and clubs against the FPL squad rules, leaving out
+// the budget. Once the season has started the budget is checked against the bank instead, as prices change.
+func ValidateComposition(squad Squad, rules Rules) []string {
+	violations := make([]string, 0)
+
+	// Check the squad has the right number of players in each position
+	for _, position := range database.Positions {
+		if count := len(squad.Position(position)); count != rules.SquadQuotas[position] {
+			violations = append(violations, fmt.Sprintf(
+				"%v players in position %v, instead of %v", count, position.Name(), rules.SquadQuotas[position],
+			))
+		}
+	}
+
+	// Check there are no duplicate players, and no more than the limit from any club
+	playerCounts := make(map[database.PlayerID]int)
+	clubCounts := make(map[int]int)
+	for _, player := range squad.Players() {
+		playerCounts[player.ID]++
+		clubCounts[player.Team]++
+	}
+	for playerID, count := range playerCounts {
+		if count > 1 {
+			violations = append(violations, fmt.Sprintf("Player %v picked %v times", playerID, count))
+		}
+	}
+	for club, count := range clubCounts {
+		if count > rules.ClubLimit {
+			violations = append(violations, fmt.Sprintf(
+				"%v players from club %v, above the limit of %v", count, club, rules.ClubLimit,
+			))
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/icelolly/go-errors"
+)
+
+/*	WORKERS:
+	This file of code runs jobs concurrently across a fixed number of goroutines.
+	Each worker takes the next job as soon as it finishes its last one, so a slow job
+	only holds up its own worker. The number of workers is kept in line with the
+	database connection limit, so the workers never wait on each other for a connection.
+*/
+
+// WorkerPool runs jobs across a fixed number of workers
+type WorkerPool struct {
+	workers int
+}
+
+// NewWorkerPool creates a pool with the number of workers given, using a single worker if fewer are given
+func NewWorkerPool(workers int) WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	return WorkerPool{workers: workers}
+}
+
+// Workers returns the number of jobs the pool runs at once
+func (p WorkerPool) Workers() int {
+	return p.workers
+}
+
+// JobError is the error returned by a single job, along with the job's number
+type JobError struct {
+	Job int
+	Err error
+}
+
+// Error returns the job's error message, labelled with the job's number
+func (e JobError) Error() string {
+	return fmt.Sprintf("Job %v: %v", e.Job, e.Err)
+}
+
+// Run calls work for every job from 0 up to the number of jobs, and returns the errors of any failed jobs in
+// the order of the jobs. Once the context is cancelled no more jobs are started, and the context's error is
+// returned after those of the jobs.
+func (p WorkerPool) Run(ctx context.Context, jobs int, work func(ctx context.Context, job int) error) []error {
+	jobCh := make(chan int)
+	jobErrors := make([]error, jobs)
+
+	// Start the workers, which each take jobs until there are none left
+	wg := &sync.WaitGroup{}
+	wg.Add(p.workers)
+	for i := 0; i < p.workers; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobCh {
+				if err := work(ctx, job); err != nil {
+					jobErrors[job] = JobError{Job: job, Err: err}
+				}
+			}
+		}()
+	}
+
+	// Hand out the jobs, stopping early if the context is cancelled
+	cancelled := false
+	for job := 0; job < jobs && !cancelled; job++ {
+		select {
+		case jobCh <- job:
+		case <-ctx.Done():
+			cancelled = true
+		}
+	}
+	close(jobCh)
+	wg.Wait()
+
+	errs := make([]error, 0)
+	for _, err := range jobErrors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if cancelled {
+		errs = append(errs, errors.Wrap(ctx.Err()))
+	}
+	return errs
+}